@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/rk-the-dev/golib-core/pkg/cronmanager"
 )
 
@@ -11,42 +15,65 @@ func main() {
 	// Create a new CronManager instance
 	cm := cronmanager.New()
 
-	// 1️⃣ Simple Job: No Parameters
-	cm.AddJob("job1", "*/5 * * * * *", func() {
+	// 1️⃣ Simple Job
+	if err := cm.AddJob("job1", "*/5 * * * * *", func(ctx context.Context) error {
 		fmt.Println("🔹 Simple Job executed at:", time.Now())
-	})
-
-	// 2️⃣ Job with One Parameter
-	printMessage := func(message string) {
-		fmt.Println("📢 Message Job:", message)
+		return nil
+	}); err != nil {
+		log.Fatal(err)
 	}
-	cm.AddJob("job2", "*/10 * * * * *", printMessage, "Hello from cron!")
 
-	// 3️⃣ Job with Multiple Parameters
-	processData := func(id int, name string) {
-		fmt.Printf("📦 Processing Data: ID=%d, Name=%s\n", id, name)
+	// 2️⃣ Job with Parameters: capture them in a closure
+	processData := func(id int, name string) cronmanager.Task {
+		return func(ctx context.Context) error {
+			fmt.Printf("📦 Processing Data: ID=%d, Name=%s\n", id, name)
+			return nil
+		}
+	}
+	if err := cm.AddJob("job2", "*/10 * * * * *", processData(101, "Kishan")); err != nil {
+		log.Fatal(err)
 	}
-	cm.AddJob("job3", "*/15 * * * * *", processData, 101, "Kishan")
 
-	// 4️⃣ Job with Struct Parameter
-	type TaskDetails struct {
-		ID    int
-		Owner string
+	// 3️⃣ Job that stops early when the CronManager is stopped
+	if err := cm.AddJob("job3", "*/15 * * * * *", func(ctx context.Context) error {
+		select {
+		case <-time.After(3 * time.Second):
+			fmt.Println("⏳ Long Job finished")
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}); err != nil {
+		log.Fatal(err)
 	}
-	processTask := func(task TaskDetails) {
-		fmt.Printf("📝 Processing Task: ID=%d, Owner=%s\n", task.ID, task.Owner)
+
+	// 4️⃣ Job with its own Error Handler
+	if err := cm.AddJob("job4", "*/20 * * * * *", func(ctx context.Context) error {
+		return errors.New("upstream unavailable")
+	}, cronmanager.WithErrorHandler(func(name string, err error) {
+		fmt.Printf("❌ %s failed: %v\n", name, err)
+	})); err != nil {
+		log.Fatal(err)
 	}
-	cm.AddJob("job4", "*/20 * * * * *", processTask, TaskDetails{ID: 1, Owner: "Admin"})
 
-	// 5️⃣ Job with Variadic Parameters
-	logData := func(params ...interface{}) {
-		fmt.Println("📝 Log Data:", params)
+	// 5️⃣ Long Job: recover panics, time out after 20s and skip ticks while running
+	if err := cm.AddJob("job5", "*/10 * * * * *", func(ctx context.Context) error {
+		select {
+		case <-time.After(15 * time.Second):
+			fmt.Println("🐢 Slow Job finished")
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, cronmanager.WithRecover(), cronmanager.WithTimeout(20*time.Second), cronmanager.WithOverlap(cronmanager.OverlapSkip)); err != nil {
+		log.Fatal(err)
 	}
-	cm.AddJob("job5", "*/25 * * * * *", logData, "Log1", 123, true)
 
 	// Start the cron manager (All jobs will start executing automatically)
 	cm.Start()
 
-	// Run indefinitely
-	select {}
+	// Serve job status at http://localhost:8080/cron/jobs
+	app := fiber.New()
+	cm.RegisterRoutes(app)
+	log.Fatal(app.Listen(":8080"))
 }
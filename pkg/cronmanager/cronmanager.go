@@ -1,61 +1,188 @@
 package cronmanager
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"reflect"
 	"sync"
+	"time"
 
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
 	"github.com/robfig/cron/v3"
 )
 
+// Task is the function run by a job. The context is cancelled when the
+// CronManager stops.
+type Task func(ctx context.Context) error
+
+// ErrorHandler is called when a run of the named job returns an error
+type ErrorHandler func(name string, err error)
+
+// OverlapPolicy decides what happens when a job is due while its previous run
+// is still in progress
+type OverlapPolicy int
+
+const (
+	OverlapAllow OverlapPolicy = iota // Start the new run concurrently
+	OverlapSkip                       // Skip the new run
+	// OverlapQueue starts the new run once the previous one returns. With a
+	// Coordinator the run is acquired when it is due, so a LockCoordinator
+	// holds the run's lock while it waits; keep its ttl above the longest wait
+	// so no other replica takes the run over.
+	OverlapQueue
+)
+
 type Job struct {
 	ID       cron.EntryID
 	Schedule string
-	Task     interface{} // Generic function
-	Args     []interface{}
+	Task     Task
+	OnError  ErrorHandler
+	Recover  bool
+	Timeout  time.Duration
+	Overlap  OverlapPolicy
 }
 
 type CronManager struct {
-	cron  *cron.Cron
-	jobs  map[string]Job
-	mutex sync.Mutex
+	cron        *cron.Cron
+	jobs        map[string]Job
+	states      map[string]*jobState
+	mutex       sync.Mutex
+	coordinator Coordinator
+	metrics     metricshelper.MetricsHelper
+	onError     ErrorHandler
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// JobOption configures a single job
+type JobOption func(*Job)
+
+// WithErrorHandler handles the errors returned by the job, replacing the
+// manager's default handler
+func WithErrorHandler(handler ErrorHandler) JobOption {
+	return func(j *Job) {
+		j.OnError = handler
+	}
+}
+
+// WithRecover recovers panics in the job and logs them with the stack trace
+func WithRecover() JobOption {
+	return func(j *Job) {
+		j.Recover = true
+	}
+}
+
+// WithTimeout cancels the job's context when a run exceeds timeout
+func WithTimeout(timeout time.Duration) JobOption {
+	return func(j *Job) {
+		j.Timeout = timeout
+	}
+}
+
+// WithOverlap sets the overlap policy of the job; the default is OverlapAllow
+func WithOverlap(policy OverlapPolicy) JobOption {
+	return func(j *Job) {
+		j.Overlap = policy
+	}
+}
+
+// WithDefaultErrorHandler handles the errors of jobs without their own
+// handler. By default errors are logged.
+func WithDefaultErrorHandler(handler ErrorHandler) Option {
+	return func(c *CronManager) {
+		c.onError = handler
+	}
+}
+
+// logError is the default ErrorHandler
+func logError(name string, err error) {
+	log.Printf("Job failed: %s, error: %v", name, err)
 }
 
 // New creates a new CronManager instance
-func New() *CronManager {
-	return &CronManager{
-		cron: cron.New(cron.WithSeconds()),
-		jobs: make(map[string]Job),
+func New(opts ...Option) *CronManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CronManager{
+		cron:    cron.New(cron.WithSeconds()),
+		jobs:    make(map[string]Job),
+		states:  make(map[string]*jobState),
+		onError: logError,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// lifecycle is implemented by coordinators that run in the background
+type lifecycle interface {
+	Start()
+	Stop()
 }
 
 // Start begins the cron scheduler
 func (c *CronManager) Start() {
+	c.mutex.Lock()
+	if c.ctx.Err() != nil {
+		c.ctx, c.cancel = context.WithCancel(context.Background())
+	}
+	c.mutex.Unlock()
+
+	if l, ok := c.coordinator.(lifecycle); ok {
+		l.Start()
+	}
 	c.cron.Start()
 	log.Println("CronManager started")
 }
 
-// Stop halts the cron scheduler
+// Stop halts the cron scheduler, cancels the context of running jobs and
+// waits for them to return
 func (c *CronManager) Stop() {
-	c.cron.Stop()
+	done := c.cron.Stop()
+	c.mutex.Lock()
+	c.cancel()
+	c.mutex.Unlock()
+	<-done.Done()
+	if l, ok := c.coordinator.(lifecycle); ok {
+		l.Stop()
+	}
 	log.Println("CronManager stopped")
 }
 
-// AddJob schedules a new job with parameters
-func (c *CronManager) AddJob(name, schedule string, task interface{}, args ...interface{}) error {
+// AddJob schedules task under a unique name
+func (c *CronManager) AddJob(name, schedule string, task Task, opts ...JobOption) error {
+	if name == "" {
+		return errors.New("job name is required")
+	}
+	if task == nil {
+		return fmt.Errorf("job %s has no task", name)
+	}
+	job := Job{Schedule: schedule, Task: task}
+	for _, opt := range opts {
+		opt(&job)
+	}
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	wrappedTask := func() {
-		invokeFunction(task, args...)
+	if _, exists := c.jobs[name]; exists {
+		return fmt.Errorf("job %s already exists", name)
 	}
 
-	id, err := c.cron.AddFunc(schedule, wrappedTask)
+	state := &jobState{status: JobStatus{Name: name, Schedule: schedule}}
+	id, err := c.cron.AddJob(schedule, c.chain(name, job).Then(cron.FuncJob(func() {
+		c.run(name, job, state)
+	})))
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid schedule for job %s: %w", name, err)
 	}
 
-	c.jobs[name] = Job{ID: id, Schedule: schedule, Task: task, Args: args}
+	job.ID = id
+	c.jobs[name] = job
+	c.states[name] = state
 	log.Printf("Added job: %s, schedule: %s", name, schedule)
 	return nil
 }
@@ -68,30 +195,108 @@ func (c *CronManager) RemoveJob(name string) {
 	if job, exists := c.jobs[name]; exists {
 		c.cron.Remove(job.ID)
 		delete(c.jobs, name)
+		delete(c.states, name)
 		log.Printf("Removed job: %s", name)
 	}
 }
 
-// ListJobs returns all scheduled jobs
+// ListJobs returns a copy of all scheduled jobs; use Statuses for their runtime state
 func (c *CronManager) ListJobs() map[string]Job {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	return c.jobs
+	jobs := make(map[string]Job, len(c.jobs))
+	for name, job := range c.jobs {
+		jobs[name] = job
+	}
+	return jobs
+}
+
+// chain returns the wrappers applied to each run of a job. Coordination runs
+// before the overlap policy, so a queued run keeps the run time it was due at.
+func (c *CronManager) chain(name string, job Job) cron.Chain {
+	// PrintfLogger reports recovered panics but not skipped or delayed runs
+	logger := cron.PrintfLogger(log.New(log.Writer(), "job "+name+": ", log.Flags()))
+	var wrappers []cron.JobWrapper
+	if job.Recover {
+		wrappers = append(wrappers, cron.Recover(logger))
+	}
+	wrappers = append(wrappers, c.coordinate(name))
+	switch job.Overlap {
+	case OverlapSkip:
+		wrappers = append(wrappers, cron.SkipIfStillRunning(logger))
+	case OverlapQueue:
+		wrappers = append(wrappers, cron.DelayIfStillRunning(logger))
+	}
+	return cron.NewChain(wrappers...)
+}
+
+// coordinate skips the runs of a job that another replica executes
+func (c *CronManager) coordinate(name string) cron.JobWrapper {
+	return func(j cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+			if c.acquire(c.runContext(), name) {
+				j.Run()
+			}
+		})
+	}
 }
 
-// invokeFunction dynamically calls a function with parameters
-func invokeFunction(fn interface{}, args ...interface{}) {
-	funcValue := reflect.ValueOf(fn)
-	if funcValue.Kind() != reflect.Func {
-		log.Println("Error: Task is not a function")
-		return
+// runContext returns the context of the current scheduler run
+func (c *CronManager) runContext() context.Context {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ctx
+}
+
+// run executes one scheduled run of a job, records it and reports its error.
+// A panic is recorded as a failure before it reaches the Recover wrapper.
+func (c *CronManager) run(name string, job Job, state *jobState) {
+	ctx := c.runContext()
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
 	}
 
-	var inputArgs []reflect.Value
-	for _, arg := range args {
-		inputArgs = append(inputArgs, reflect.ValueOf(arg))
+	start := time.Now()
+	state.begin(start)
+	defer func() {
+		if r := recover(); r != nil {
+			c.record(name, state, start, fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+	}()
+	err := job.Task(ctx)
+	c.record(name, state, start, err)
+
+	if err != nil {
+		handler := job.OnError
+		if handler == nil {
+			handler = c.onError
+		}
+		handler(name, err)
 	}
+}
 
-	funcValue.Call(inputArgs)
+// acquire asks the coordinator whether this replica executes the current run of a job
+func (c *CronManager) acquire(ctx context.Context, name string) bool {
+	if c.coordinator == nil {
+		return true
+	}
+	c.mutex.Lock()
+	job, exists := c.jobs[name]
+	c.mutex.Unlock()
+	if !exists {
+		return false
+	}
+	// The scheduler records the run as Prev before the entry can be read, so
+	// every replica derives the same run time from the schedule
+	runAt := c.cron.Entry(job.ID).Prev
+	ok, err := c.coordinator.Acquire(ctx, name, runAt)
+	if err != nil {
+		log.Printf("Skipped job: %s, coordination failed: %v", name, err)
+		return false
+	}
+	return ok
 }
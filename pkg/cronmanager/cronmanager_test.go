@@ -0,0 +1,294 @@
+package cronmanager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// everySecond is the shortest schedule the scheduler supports
+const everySecond = "* * * * * *"
+
+func TestAddJobValidation(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	c := New()
+	if err := c.AddJob("existing", everySecond, noop); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		job      string
+		schedule string
+		task     Task
+	}{
+		{"empty name", "", everySecond, noop},
+		{"nil task", "nil", everySecond, nil},
+		{"duplicate name", "existing", everySecond, noop},
+		{"invalid schedule", "invalid", "every day", noop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.AddJob(tt.job, tt.schedule, tt.task); err == nil {
+				t.Fatal("AddJob succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestOverlapPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  OverlapPolicy
+		minRuns int32
+		maxRuns int32
+		maxConc int32
+	}{
+		// Ticks at about 1s, 2s and 3s; each run takes 1.5s
+		{"allow", OverlapAllow, 3, 3, 2},
+		{"skip", OverlapSkip, 2, 2, 1},
+		{"queue", OverlapQueue, 2, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var runs, running, maxRunning atomic.Int32
+			c := New()
+			err := c.AddJob("report", everySecond, func(context.Context) error {
+				runs.Add(1)
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(1500 * time.Millisecond)
+				return nil
+			}, WithOverlap(tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Start()
+			waitForSecond(3)
+			c.Stop()
+
+			if n := runs.Load(); n < tt.minRuns || n > tt.maxRuns {
+				t.Errorf("%d runs, want %d-%d", n, tt.minRuns, tt.maxRuns)
+			}
+			if n := maxRunning.Load(); n > tt.maxConc {
+				t.Errorf("%d concurrent runs, want at most %d", n, tt.maxConc)
+			}
+		})
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	failure := errors.New("upstream unavailable")
+	var calls atomic.Int32
+	var mu sync.Mutex
+	var handled []error
+	c := New()
+	err := c.AddJob("sync", everySecond, func(context.Context) error {
+		if calls.Add(1) <= 2 {
+			return failure
+		}
+		return nil
+	}, WithErrorHandler(func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddJob("crash", everySecond, func(context.Context) error { panic("nil map") }, WithRecover()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Start()
+	waitForSecond(2)
+	mid, _ := c.Status("sync")
+	waitForSecond(1)
+	c.Stop()
+
+	if mid.RunCount != 2 || mid.ConsecutiveFailures != 2 || mid.LastError != failure.Error() || !mid.LastSuccess.IsZero() {
+		t.Errorf("after two failures: %+v", mid)
+	}
+	if mid.NextRun.Before(mid.LastRun) {
+		t.Errorf("next run %s before last run %s", mid.NextRun, mid.LastRun)
+	}
+	final, ok := c.Status("sync")
+	if !ok {
+		t.Fatal("status of sync not found")
+	}
+	if final.RunCount != 3 || final.FailureCount != 2 || final.ConsecutiveFailures != 0 || final.LastError != "" || final.LastSuccess.IsZero() {
+		t.Errorf("after recovery: %+v", final)
+	}
+	mu.Lock()
+	if len(handled) != 2 {
+		t.Errorf("error handler called %d times, want 2", len(handled))
+	}
+	mu.Unlock()
+
+	crash, _ := c.Status("crash")
+	if crash.RunCount != 3 || crash.ConsecutiveFailures != 3 || crash.LastError != "panic: nil map" {
+		t.Errorf("panicking job: %+v", crash)
+	}
+	if _, ok := c.Status("missing"); ok {
+		t.Error("status found for a missing job")
+	}
+	if statuses := c.Statuses(); len(statuses) != 2 || statuses[0].Name != "crash" || statuses[1].Name != "sync" {
+		t.Errorf("statuses not sorted by name: %+v", statuses)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	errs := make(chan error, 1)
+	c := New()
+	err := c.AddJob("slow", everySecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(50*time.Millisecond), WithOverlap(OverlapSkip), WithErrorHandler(func(_ string, err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	defer c.Stop()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("run failed with %v, want a deadline error", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("run did not time out")
+	}
+}
+
+func TestLockCoordinatorRunsOncePerTick(t *testing.T) {
+	locker := newMemoryLocker()
+	var runs atomic.Int32
+	for range 3 {
+		c := New(WithCoordinator(NewLockCoordinator(locker, time.Minute)))
+		if err := c.AddJob("nightly", everySecond, func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		c.Start()
+		defer c.Stop()
+	}
+	waitForSecond(2)
+
+	locker.mu.Lock()
+	defer locker.mu.Unlock()
+	n := int(runs.Load())
+	if n == 0 || n != len(locker.owners) {
+		t.Fatalf("%d runs for %d scheduled ticks across 3 replicas", n, len(locker.owners))
+	}
+	for key, attempts := range locker.attempts {
+		if attempts != 3 {
+			t.Errorf("%s: %d lock attempts, want one per replica", key, attempts)
+		}
+	}
+}
+
+func TestLockCoordinatorRestarts(t *testing.T) {
+	locker := &expiringLocker{memoryLocker: newMemoryLocker()}
+	coordinator := NewLockCoordinator(locker, 20*time.Millisecond)
+	for i := range 2 {
+		before := locker.deletes.Load()
+		coordinator.Start()
+		time.Sleep(100 * time.Millisecond)
+		coordinator.Stop()
+		if locker.deletes.Load() == before {
+			t.Fatalf("start %d: expired locks were not deleted", i+1)
+		}
+	}
+	stopped := locker.deletes.Load()
+	time.Sleep(100 * time.Millisecond)
+	if locker.deletes.Load() != stopped {
+		t.Error("expired locks deleted after Stop")
+	}
+}
+
+func TestLeaderCoordinatorRestarts(t *testing.T) {
+	leader := NewLeaderCoordinator(newMemoryLocker(), "billing", time.Minute)
+	var runs atomic.Int32
+	c := New(WithCoordinator(leader))
+	if err := c.AddJob("invoice", everySecond, func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Start()
+	if !leader.IsLeader() {
+		t.Fatal("not leader after Start")
+	}
+	c.Stop()
+	if leader.IsLeader() {
+		t.Fatal("still leader after Stop")
+	}
+	c.Start()
+	defer c.Stop()
+	if !leader.IsLeader() {
+		t.Fatal("not leader after restart")
+	}
+	waitForSecond(1)
+	if runs.Load() == 0 {
+		t.Fatal("no runs after restart")
+	}
+}
+
+// memoryLocker is an in-process Locker shared by the replicas of a test. It
+// counts the lock attempts on each key.
+type memoryLocker struct {
+	mu       sync.Mutex
+	owners   map[string]string
+	attempts map[string]int
+}
+
+func newMemoryLocker() *memoryLocker {
+	return &memoryLocker{owners: make(map[string]string), attempts: make(map[string]int)}
+}
+
+func (l *memoryLocker) TryLock(_ context.Context, key, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts[key]++
+	if current, ok := l.owners[key]; ok && current != owner {
+		return false, nil
+	}
+	l.owners[key] = owner
+	return true, nil
+}
+
+// expiringLocker counts the DeleteExpired calls of the LockCoordinator cleanup
+type expiringLocker struct {
+	*memoryLocker
+	deletes atomic.Int32
+}
+
+func (l *expiringLocker) DeleteExpired() (int64, error) {
+	l.deletes.Add(1)
+	return 0, nil
+}
+
+// waitForSecond sleeps until just after the n-th upcoming full second, so
+// every-second jobs have ticked exactly n times
+func waitForSecond(n int) {
+	now := time.Now()
+	next := now.Truncate(time.Second).Add(time.Duration(n) * time.Second)
+	time.Sleep(next.Sub(now) + 200*time.Millisecond)
+}
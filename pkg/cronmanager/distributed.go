@@ -0,0 +1,228 @@
+package cronmanager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Coordinator decides which replica executes a scheduled run
+type Coordinator interface {
+	// Acquire reports whether this replica executes the run of job scheduled at runAt
+	Acquire(ctx context.Context, job string, runAt time.Time) (bool, error)
+}
+
+// Locker provides expiring locks shared by all replicas
+type Locker interface {
+	// TryLock takes key for owner until ttl passes. If owner already holds the
+	// lock it is extended; if another owner holds it, TryLock returns false.
+	TryLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+}
+
+// Option configures a CronManager
+type Option func(*CronManager)
+
+// WithCoordinator makes every job run only on the replica the coordinator picks
+func WithCoordinator(coordinator Coordinator) Option {
+	return func(c *CronManager) {
+		c.coordinator = coordinator
+	}
+}
+
+// expirer is implemented by lockers that keep expired locks until they are deleted
+type expirer interface {
+	DeleteExpired() (int64, error)
+}
+
+// LockCoordinator lets the first replica to lock a run execute it. Runs are
+// identified by job name and scheduled time, which are the same on every replica.
+type LockCoordinator struct {
+	locker Locker
+	owner  string
+	ttl    time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{} // Closed by Stop, nil while stopped
+	wg   sync.WaitGroup
+}
+
+// NewLockCoordinator creates a LockCoordinator. The lock is kept for ttl after
+// the run starts, so ttl must exceed the clock skew between replicas; it
+// defaults to 1m.
+func NewLockCoordinator(locker Locker, ttl time.Duration) *LockCoordinator {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &LockCoordinator{locker: locker, owner: newOwnerID(), ttl: ttl}
+}
+
+// Acquire locks the run
+func (l *LockCoordinator) Acquire(ctx context.Context, job string, runAt time.Time) (bool, error) {
+	return l.locker.TryLock(ctx, "cron:"+job+":"+runAt.UTC().Format(time.RFC3339), l.owner, l.ttl)
+}
+
+// Start deletes expired run locks every ttl when the locker keeps them, as
+// SQLLocker does. CronManager.Start calls it.
+func (l *LockCoordinator) Start() {
+	e, ok := l.locker.(expirer)
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		return
+	}
+	l.stop = make(chan struct{})
+	l.wg.Add(1)
+	go l.cleanup(e, l.stop)
+}
+
+// Stop stops the cleanup; Start may be called again afterwards. CronManager.Stop calls it.
+func (l *LockCoordinator) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop == nil {
+		return
+	}
+	close(l.stop)
+	l.wg.Wait()
+	l.stop = nil
+}
+
+// cleanup deletes expired locks every ttl until stop is closed
+func (l *LockCoordinator) cleanup(e expirer, stop <-chan struct{}) {
+	defer l.wg.Done()
+	ticker := time.NewTicker(l.ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := e.DeleteExpired(); err != nil {
+				log.Printf("Cron lock cleanup failed: %v", err)
+			}
+		}
+	}
+}
+
+// LeaderCoordinator runs every job on one elected replica. The leader renews
+// its lease every ttl/3; when it stops renewing, another replica takes over
+// once the lease expires.
+type LeaderCoordinator struct {
+	locker Locker
+	key    string
+	owner  string
+	ttl    time.Duration
+
+	leaseUntil atomic.Int64 // Unix nanoseconds until which this replica holds the lease
+	mu         sync.Mutex
+	stop       chan struct{} // Closed by Stop, nil while stopped
+	wg         sync.WaitGroup
+}
+
+// NewLeaderCoordinator creates a LeaderCoordinator competing for the lease
+// named name, e.g. the service name. ttl defaults to 15s.
+func NewLeaderCoordinator(locker Locker, name string, ttl time.Duration) *LeaderCoordinator {
+	if ttl <= 0 {
+		ttl = 15 * time.Second
+	}
+	return &LeaderCoordinator{
+		locker: locker,
+		key:    "cron:leader:" + name,
+		owner:  newOwnerID(),
+		ttl:    ttl,
+	}
+}
+
+// Start begins competing for leadership. CronManager.Start calls it.
+func (l *LeaderCoordinator) Start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		return
+	}
+	l.stop = make(chan struct{})
+	l.renew()
+	l.wg.Add(1)
+	go l.loop(l.stop)
+}
+
+// Stop stops renewing the lease; Start may be called again afterwards.
+// CronManager.Stop calls it.
+func (l *LeaderCoordinator) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop == nil {
+		return
+	}
+	close(l.stop)
+	l.wg.Wait()
+	l.stop = nil
+	l.leaseUntil.Store(0)
+}
+
+// IsLeader reports whether this replica currently holds the lease
+func (l *LeaderCoordinator) IsLeader() bool {
+	return time.Now().UnixNano() < l.leaseUntil.Load()
+}
+
+// Acquire reports whether this replica is the leader
+func (l *LeaderCoordinator) Acquire(_ context.Context, _ string, _ time.Time) (bool, error) {
+	return l.IsLeader(), nil
+}
+
+// loop renews the lease until stop is closed
+func (l *LeaderCoordinator) loop(stop <-chan struct{}) {
+	defer l.wg.Done()
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.renew()
+		}
+	}
+}
+
+// renew tries to take or extend the lease
+func (l *LeaderCoordinator) renew() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+	defer cancel()
+	start := time.Now()
+	wasLeader := l.IsLeader()
+	ok, err := l.locker.TryLock(ctx, l.key, l.owner, l.ttl)
+	if err != nil {
+		log.Printf("Cron leader election failed: %v", err)
+		return // Keep the current lease until it expires
+	}
+	if !ok {
+		l.leaseUntil.Store(0)
+		if wasLeader {
+			log.Printf("Cron leadership lost: %s", l.owner)
+		}
+		return
+	}
+	// Count the lease from before the request so it never outlives the store's copy
+	l.leaseUntil.Store(start.Add(l.ttl).UnixNano())
+	if !wasLeader {
+		log.Printf("Cron leadership acquired: %s", l.owner)
+	}
+}
+
+// newOwnerID identifies this replica as hostname plus a random suffix
+func newOwnerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%s", host, hex.EncodeToString(b))
+}
@@ -0,0 +1,113 @@
+package cronmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rk-the-dev/golib-core/pkg/database/sqldialect"
+)
+
+// lockScript sets the key for the owner unless another owner holds it
+var lockScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if not value then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+if value == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// RedisLocker keeps locks as expiring Redis keys
+type RedisLocker struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisLocker creates a RedisLocker; keys are prefixed with prefix, e.g. "billing:"
+func NewRedisLocker(client redis.Cmdable, prefix string) *RedisLocker {
+	return &RedisLocker{client: client, prefix: prefix}
+}
+
+// TryLock atomically takes or extends the lock
+func (l *RedisLocker) TryLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ok, err := lockScript.Run(ctx, l.client, []string{l.prefix + key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to lock %s in Redis: %w", key, err)
+	}
+	return ok == 1, nil
+}
+
+// SQLLocker keeps locks as rows with an owner and an expiry column
+type SQLLocker struct {
+	db      sqldialect.Executor
+	table   string
+	dialect sqldialect.Dialect
+}
+
+// NewSQLLocker creates a SQLLocker on table
+func NewSQLLocker(db sqldialect.Executor, table string, dialect sqldialect.Dialect) (*SQLLocker, error) {
+	if err := dialect.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cron lock config: %w", err)
+	}
+	if table == "" {
+		table = "cron_locks"
+	}
+	return &SQLLocker{db: db, table: table, dialect: dialect}, nil
+}
+
+// Schema returns the CREATE TABLE statement for the lock table
+func (l *SQLLocker) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + l.table + ` (
+	lock_key VARCHAR(255) PRIMARY KEY,
+	owner VARCHAR(255) NOT NULL,
+	expires_at ` + l.dialect.Timestamp() + ` NOT NULL
+)`
+}
+
+// EnsureTable creates the lock table if it does not exist
+func (l *SQLLocker) EnsureTable() error {
+	if err := sqldialect.EnsureTable(l.db, l.Schema()); err != nil {
+		return fmt.Errorf("failed to create cron lock table: %w", err)
+	}
+	return nil
+}
+
+// TryLock extends a lock held by owner or takes over an expired one, then
+// inserts the lock if it does not exist; each is a single conditional
+// statement, so only one concurrent owner succeeds
+func (l *SQLLocker) TryLock(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	expires := now.Add(ttl)
+
+	update := "UPDATE " + l.table + " SET owner = " + l.dialect.Param(1) + ", expires_at = " + l.dialect.Param(2) +
+		" WHERE lock_key = " + l.dialect.Param(3) + " AND (owner = " + l.dialect.Param(4) + " OR expires_at < " + l.dialect.Param(5) + ")"
+	if locked, err := l.affected(update, owner, expires, key, owner, now); err != nil || locked {
+		return locked, err
+	}
+	return l.affected(l.dialect.InsertIgnore(l.table, "lock_key", "lock_key", "owner", "expires_at"), key, owner, expires)
+}
+
+// DeleteExpired removes expired locks and returns how many were deleted.
+// LockCoordinator calls it periodically, since every run adds a row.
+func (l *SQLLocker) DeleteExpired() (int64, error) {
+	result, err := l.db.ExecuteNonQuery("DELETE FROM "+l.table+" WHERE expires_at < "+l.dialect.Param(1), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired cron locks: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// affected runs a statement and reports whether it changed a row
+func (l *SQLLocker) affected(query string, args ...interface{}) (bool, error) {
+	locked, err := sqldialect.Affected(l.db, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock cron job: %w", err)
+	}
+	return locked, nil
+}
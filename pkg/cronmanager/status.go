@@ -0,0 +1,174 @@
+package cronmanager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
+	"github.com/robfig/cron/v3"
+)
+
+// Metrics reported through WithMetrics, labelled by job
+const (
+	MetricRuns                = "cron_job_runs_total"
+	MetricFailures            = "cron_job_failures_total"
+	MetricDuration            = "cron_job_duration_seconds"
+	MetricConsecutiveFailures = "cron_job_consecutive_failures"
+	MetricLastSuccess         = "cron_job_last_success_timestamp_seconds"
+)
+
+// JobStatus is the runtime state of a job, as returned by Status and the admin endpoint
+type JobStatus struct {
+	Name                string    `json:"name"`
+	Schedule            string    `json:"schedule"`
+	Running             int       `json:"running"`
+	RunCount            int64     `json:"run_count"`
+	FailureCount        int64     `json:"failure_count"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastRun             time.Time `json:"last_run"`
+	LastDuration        float64   `json:"last_duration_seconds"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastSuccess         time.Time `json:"last_success"`
+	NextRun             time.Time `json:"next_run"`
+}
+
+// WithMetrics reports job runs, failures and durations
+func WithMetrics(metrics metricshelper.MetricsHelper) Option {
+	return func(c *CronManager) {
+		c.metrics = metrics
+	}
+}
+
+// jobState tracks the runs of a job
+type jobState struct {
+	mu     sync.Mutex
+	status JobStatus
+}
+
+// begin records the start of a run
+func (s *jobState) begin(start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running++
+	s.status.LastRun = start
+}
+
+// finish records the outcome of a run and passes the new status to publish
+// before releasing the lock, so concurrent runs publish gauges in the order
+// they finish
+func (s *jobState) finish(start time.Time, duration time.Duration, err error, publish func(status JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running--
+	s.status.RunCount++
+	s.status.LastDuration = duration.Seconds()
+	if err != nil {
+		s.status.FailureCount++
+		s.status.ConsecutiveFailures++
+		s.status.LastError = err.Error()
+	} else {
+		s.status.ConsecutiveFailures = 0
+		s.status.LastError = ""
+		s.status.LastSuccess = start
+	}
+	publish(s.status)
+}
+
+// snapshot returns a copy of the status
+func (s *jobState) snapshot() JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// record updates the job state and metrics after a run
+func (c *CronManager) record(name string, state *jobState, start time.Time, err error) {
+	duration := time.Since(start)
+	labels := map[string]string{"job": name}
+	state.finish(start, duration, err, func(status JobStatus) {
+		if c.metrics == nil {
+			return
+		}
+		c.metrics.SetGauge(MetricConsecutiveFailures, float64(status.ConsecutiveFailures), labels)
+		if !status.LastSuccess.IsZero() {
+			c.metrics.SetGauge(MetricLastSuccess, float64(status.LastSuccess.Unix()), labels)
+		}
+	})
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.IncrementCounter(MetricRuns, labels)
+	c.metrics.ObserveHistogram(MetricDuration, duration.Seconds(), labels)
+	if err != nil {
+		c.metrics.IncrementCounter(MetricFailures, labels)
+	}
+}
+
+// Status returns a snapshot of the named job's state
+func (c *CronManager) Status(name string) (JobStatus, bool) {
+	c.mutex.Lock()
+	job, exists := c.jobs[name]
+	state := c.states[name]
+	c.mutex.Unlock()
+	if !exists {
+		return JobStatus{}, false
+	}
+	status := state.snapshot()
+	status.NextRun = c.cron.Entry(job.ID).Next
+	return status, true
+}
+
+// Statuses returns a snapshot of every job's state, sorted by name
+func (c *CronManager) Statuses() []JobStatus {
+	c.mutex.Lock()
+	states := make(map[string]*jobState, len(c.states))
+	for name, state := range c.states {
+		states[name] = state
+	}
+	jobs := make(map[string]Job, len(c.jobs))
+	for name, job := range c.jobs {
+		jobs[name] = job
+	}
+	c.mutex.Unlock()
+
+	next := make(map[cron.EntryID]time.Time)
+	for _, entry := range c.cron.Entries() {
+		next[entry.ID] = entry.Next
+	}
+	statuses := make([]JobStatus, 0, len(states))
+	for name, state := range states {
+		status := state.snapshot()
+		status.NextRun = next[jobs[name].ID]
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// StatusHandler serves the state of every job
+func (c *CronManager) StatusHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return ctx.JSON(c.Statuses())
+	}
+}
+
+// JobStatusHandler serves the state of the job named by the :name parameter
+func (c *CronManager) JobStatusHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		name := ctx.Params("name")
+		status, ok := c.Status(name)
+		if !ok {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("job %s not found", name)})
+		}
+		return ctx.JSON(status)
+	}
+}
+
+// RegisterRoutes mounts /cron/jobs and /cron/jobs/:name on the given router
+func (c *CronManager) RegisterRoutes(router fiber.Router) {
+	router.Get("/cron/jobs", c.StatusHandler())
+	router.Get("/cron/jobs/:name", c.JobStatusHandler())
+}
@@ -0,0 +1,54 @@
+package cassandra
+
+import (
+	"github.com/gocql/gocql"
+)
+
+// BatchType selects how Cassandra applies a batch
+type BatchType = gocql.BatchType
+
+const (
+	// LoggedBatch guarantees all statements eventually apply, at the cost of a batch log write
+	LoggedBatch = gocql.LoggedBatch
+	// UnloggedBatch skips the batch log; use it for writes to a single partition
+	UnloggedBatch = gocql.UnloggedBatch
+	// CounterBatch is required for counter updates
+	CounterBatch = gocql.CounterBatch
+)
+
+// batchEntry is a single statement queued in a Batch
+type batchEntry struct {
+	stmt string
+	args []any
+}
+
+// Batch collects statements to be executed together with ExecuteBatch or ExecuteBatchCAS
+type Batch struct {
+	kind    BatchType
+	entries []batchEntry
+}
+
+// NewBatch creates an empty batch of the given type
+func NewBatch(kind BatchType) *Batch {
+	return &Batch{kind: kind}
+}
+
+// Query appends a statement to the batch
+func (b *Batch) Query(stmt string, args ...any) *Batch {
+	b.entries = append(b.entries, batchEntry{stmt: stmt, args: args})
+	return b
+}
+
+// Size returns the number of statements in the batch
+func (b *Batch) Size() int {
+	return len(b.entries)
+}
+
+// build converts the batch into a gocql batch bound to the session
+func (b *Batch) build(session *gocql.Session) *gocql.Batch {
+	batch := session.NewBatch(b.kind)
+	for _, entry := range b.entries {
+		batch.Query(entry.stmt, entry.args...)
+	}
+	return batch
+}
@@ -2,17 +2,68 @@ package cassandra
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/security"
 )
 
+// ErrNotFound is returned when a query that expects a row returns none
+var ErrNotFound = errors.New("no rows found")
+
+// Config defines Cassandra cluster and session settings
 type Config struct {
-	Hosts    []string `mapstructure:"hosts"`
-	Keyspace string   `mapstructure:"keyspace"`
-	Username string   `mapstructure:"username"`
-	Password string   `mapstructure:"password"`
+	Hosts    []string `mapstructure:"hosts" env:"CASSANDRA_HOSTS" envSeparator:"," envDefault:"localhost"`
+	Port     int      `mapstructure:"port" env:"CASSANDRA_PORT" envDefault:"9042"`
+	Keyspace string   `mapstructure:"keyspace" env:"CASSANDRA_KEYSPACE"`
+	Username string   `mapstructure:"username" env:"CASSANDRA_USERNAME"`
+	Password string   `mapstructure:"password" env:"CASSANDRA_PASSWORD"`
+
+	// Consistency is the default for every query, e.g. ONE, LOCAL_QUORUM, QUORUM, ALL.
+	// Override it per query with WithConsistency.
+	Consistency string `mapstructure:"consistency" env:"CASSANDRA_CONSISTENCY" envDefault:"QUORUM"`
+	// SerialConsistency applies to lightweight transactions: SERIAL or LOCAL_SERIAL
+	SerialConsistency string `mapstructure:"serial_consistency" env:"CASSANDRA_SERIAL_CONSISTENCY" envDefault:"SERIAL"`
+
+	Timeout        time.Duration `mapstructure:"timeout" env:"CASSANDRA_TIMEOUT" envDefault:"10s"`
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout" env:"CASSANDRA_CONNECT_TIMEOUT" envDefault:"10s"`
+	NumConns       int           `mapstructure:"num_conns" env:"CASSANDRA_NUM_CONNS" envDefault:"2"` // Connections per host
+	PageSize       int           `mapstructure:"page_size" env:"CASSANDRA_PAGE_SIZE" envDefault:"5000"`
+	ProtoVersion   int           `mapstructure:"proto_version" env:"CASSANDRA_PROTO_VERSION" envDefault:"0"` // 0 negotiates with the cluster
+
+	// Retry policy: exponential backoff between MinBackoff and MaxBackoff
+	RetryNumRetries int           `mapstructure:"retry_num_retries" env:"CASSANDRA_RETRY_NUM_RETRIES" envDefault:"3"`
+	RetryMinBackoff time.Duration `mapstructure:"retry_min_backoff" env:"CASSANDRA_RETRY_MIN_BACKOFF" envDefault:"100ms"`
+	RetryMaxBackoff time.Duration `mapstructure:"retry_max_backoff" env:"CASSANDRA_RETRY_MAX_BACKOFF" envDefault:"2s"`
+
+	// Speculative execution sends extra attempts to other hosts after SpeculativeDelay.
+	// It only applies to idempotent queries (see DefaultIdempotent and WithIdempotent).
+	SpeculativeAttempts int           `mapstructure:"speculative_attempts" env:"CASSANDRA_SPECULATIVE_ATTEMPTS" envDefault:"0"`
+	SpeculativeDelay    time.Duration `mapstructure:"speculative_delay" env:"CASSANDRA_SPECULATIVE_DELAY" envDefault:"100ms"`
+	DefaultIdempotent   bool          `mapstructure:"default_idempotent" env:"CASSANDRA_DEFAULT_IDEMPOTENT" envDefault:"false"`
+
+	// Host selection: round robin within LocalDC when set, routed to replicas when TokenAware
+	LocalDC         string `mapstructure:"local_dc" env:"CASSANDRA_LOCAL_DC"`
+	TokenAware      bool   `mapstructure:"token_aware" env:"CASSANDRA_TOKEN_AWARE" envDefault:"true"`
+	ShuffleReplicas bool   `mapstructure:"shuffle_replicas" env:"CASSANDRA_SHUFFLE_REPLICAS" envDefault:"false"`
+
+	TLS security.TLSConfig `mapstructure:"tls" envPrefix:"CASSANDRA_"`
 }
+
 type CassandraClient interface {
 	Exec(ctx context.Context, query string, args ...any) error
 	QueryOne(ctx context.Context, query string, args ...any) (map[string]any, error)
 	QueryAll(ctx context.Context, query string, args ...any) ([]map[string]any, error)
+	// Iter streams rows, fetching pages lazily so large partitions are never fully loaded
+	Iter(ctx context.Context, query string, args ...any) *Iterator
+	// IterPage reads a single page of at most pageSize rows starting at pageState
+	IterPage(ctx context.Context, pageSize int, pageState []byte, query string, args ...any) *Iterator
+	// ExecCAS runs a lightweight transaction (INSERT ... IF NOT EXISTS, UPDATE ... IF ...).
+	// When it did not apply, existing holds the current values of the row.
+	ExecCAS(ctx context.Context, query string, args ...any) (applied bool, existing map[string]any, err error)
+	ExecuteBatch(ctx context.Context, batch *Batch) error
+	// ExecuteBatchCAS runs a batch containing conditional statements. When it did not
+	// apply, existing holds the current rows the conditions were checked against.
+	ExecuteBatchCAS(ctx context.Context, batch *Batch) (applied bool, existing []map[string]any, err error)
 	Close()
 }
@@ -3,65 +3,209 @@ package cassandra
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/rk-the-dev/golib-core/pkg/security"
+
 	"github.com/gocql/gocql"
 )
 
 type client struct {
-	session *gocql.Session
+	session     *gocql.Session
+	speculative gocql.SpeculativeExecutionPolicy
 }
 
-func New(hosts []string, keyspace, username, password string) (CassandraClient, error) {
-	cluster := gocql.NewCluster(hosts...)
-	cluster.Keyspace = keyspace
-	cluster.Consistency = gocql.Quorum
-	cluster.Authenticator = gocql.PasswordAuthenticator{
-		Username: username,
-		Password: password,
+// New creates a Cassandra session from the given config
+func New(cfg *Config) (CassandraClient, error) {
+	cluster, err := newClusterConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassandra config: %w", err)
 	}
-	cluster.Timeout = 10 * time.Second
 
 	session, err := cluster.CreateSession()
 	if err != nil {
 		return nil, fmt.Errorf("cassandra connection failed: %w", err)
 	}
 
-	return &client{session: session}, nil
+	c := &client{session: session}
+	if cfg.SpeculativeAttempts > 0 {
+		c.speculative = &gocql.SimpleSpeculativeExecution{
+			NumAttempts:  cfg.SpeculativeAttempts,
+			TimeoutDelay: cfg.SpeculativeDelay,
+		}
+	}
+	return c, nil
+}
+
+// newClusterConfig translates Config into a gocql cluster config
+func newClusterConfig(cfg *Config) (*gocql.ClusterConfig, error) {
+	cluster := gocql.NewCluster(cfg.Hosts...)
+	cluster.Keyspace = cfg.Keyspace
+	if cfg.Port > 0 {
+		cluster.Port = cfg.Port
+	}
+	if cfg.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	}
+
+	cluster.Consistency = gocql.Quorum
+	if cfg.Consistency != "" {
+		consistency, err := gocql.ParseConsistencyWrapper(cfg.Consistency)
+		if err != nil {
+			return nil, err
+		}
+		cluster.Consistency = consistency
+	}
+	switch strings.ToUpper(cfg.SerialConsistency) {
+	case "", "SERIAL":
+		cluster.SerialConsistency = gocql.Serial
+	case "LOCAL_SERIAL":
+		cluster.SerialConsistency = gocql.LocalSerial
+	default:
+		return nil, fmt.Errorf("unknown serial consistency: %s", cfg.SerialConsistency)
+	}
+
+	cluster.Timeout = 10 * time.Second
+	if cfg.Timeout > 0 {
+		cluster.Timeout = cfg.Timeout
+	}
+	if cfg.ConnectTimeout > 0 {
+		cluster.ConnectTimeout = cfg.ConnectTimeout
+	}
+	if cfg.NumConns > 0 {
+		cluster.NumConns = cfg.NumConns
+	}
+	if cfg.PageSize > 0 {
+		cluster.PageSize = cfg.PageSize
+	}
+	if cfg.ProtoVersion > 0 {
+		cluster.ProtoVersion = cfg.ProtoVersion
+	}
+	if cfg.RetryNumRetries > 0 {
+		cluster.RetryPolicy = &gocql.ExponentialBackoffRetryPolicy{
+			NumRetries: cfg.RetryNumRetries,
+			Min:        cfg.RetryMinBackoff,
+			Max:        cfg.RetryMaxBackoff,
+		}
+	}
+	cluster.DefaultIdempotence = cfg.DefaultIdempotent
+
+	hostPolicy := gocql.RoundRobinHostPolicy()
+	if cfg.LocalDC != "" {
+		hostPolicy = gocql.DCAwareRoundRobinPolicy(cfg.LocalDC)
+	}
+	if cfg.TokenAware && cfg.ShuffleReplicas {
+		hostPolicy = gocql.TokenAwareHostPolicy(hostPolicy, gocql.ShuffleReplicas())
+	} else if cfg.TokenAware {
+		hostPolicy = gocql.TokenAwareHostPolicy(hostPolicy)
+	}
+	cluster.PoolConfig.HostSelectionPolicy = hostPolicy
+
+	tlsConfig, err := security.BuildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		cluster.SslOpts = &gocql.SslOptions{
+			Config:                 tlsConfig,
+			EnableHostVerification: !cfg.TLS.InsecureSkipVerify,
+		}
+	}
+	return cluster, nil
 }
 
 func (c *client) Exec(ctx context.Context, query string, args ...any) error {
-	return c.session.Query(query, args...).WithContext(ctx).Exec()
+	return c.query(ctx, query, args...).Exec()
 }
 
 func (c *client) QueryOne(ctx context.Context, query string, args ...any) (map[string]any, error) {
-	iter := c.session.Query(query, args...).WithContext(ctx).Iter()
-	defer iter.Close()
-
+	it := c.Iter(ctx, query, args...)
 	row := map[string]any{}
-	if !iter.MapScan(row) {
-		return nil, fmt.Errorf("no rows found")
+	found := it.MapScan(row)
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
 	}
 	return row, nil
 }
 
 func (c *client) QueryAll(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
-	iter := c.session.Query(query, args...).WithContext(ctx).Iter()
-	defer iter.Close()
-
+	it := c.Iter(ctx, query, args...)
 	var results []map[string]any
 	row := map[string]any{}
-	for iter.MapScan(row) {
-		copied := make(map[string]any)
-		for k, v := range row {
-			copied[k] = v
-		}
-		results = append(results, copied)
+	for it.MapScan(row) {
+		results = append(results, row)
 		row = map[string]any{}
 	}
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
 	return results, nil
 }
 
+// Iter runs a query and returns a streaming iterator. gocql prepares and
+// caches the statement on first use, so repeated calls only bind values.
+func (c *client) Iter(ctx context.Context, query string, args ...any) *Iterator {
+	iter := c.query(ctx, query, args...).Iter()
+	return &Iterator{iter: iter, limit: -1}
+}
+
+// IterPage runs a query for a single page. Pass the returned PageState to continue.
+func (c *client) IterPage(ctx context.Context, pageSize int, pageState []byte, query string, args ...any) *Iterator {
+	iter := c.query(ctx, query, args...).
+		PageSize(pageSize).
+		PageState(pageState).
+		Iter()
+	return &Iterator{iter: iter, limit: iter.NumRows()}
+}
+
+func (c *client) ExecCAS(ctx context.Context, query string, args ...any) (bool, map[string]any, error) {
+	existing := map[string]any{}
+	applied, err := c.query(ctx, query, args...).MapScanCAS(existing)
+	if err != nil {
+		return false, nil, err
+	}
+	if applied {
+		return true, nil, nil
+	}
+	return false, existing, nil
+}
+
+func (c *client) ExecuteBatch(ctx context.Context, batch *Batch) error {
+	return c.session.ExecuteBatch(c.batch(ctx, batch))
+}
+
+func (c *client) ExecuteBatchCAS(ctx context.Context, batch *Batch) (bool, []map[string]any, error) {
+	row := map[string]any{}
+	applied, iter, err := c.session.MapExecuteBatchCAS(c.batch(ctx, batch), row)
+	if err != nil {
+		if iter != nil {
+			iter.Close()
+		}
+		return false, nil, err
+	}
+	if applied {
+		return true, nil, iter.Close()
+	}
+	existing := []map[string]any{row}
+	row = map[string]any{}
+	for iter.MapScan(row) {
+		delete(row, "[applied]")
+		existing = append(existing, row)
+		row = map[string]any{}
+	}
+	if err := iter.Close(); err != nil {
+		return false, nil, err
+	}
+	return false, existing, nil
+}
+
 func (c *client) Close() {
 	c.session.Close()
 }
@@ -0,0 +1,49 @@
+package cassandra
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
+
+type consistencyKey struct{}
+
+type idempotentKey struct{}
+
+// WithConsistency overrides the default consistency for queries run with the returned context
+func WithConsistency(ctx context.Context, consistency gocql.Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, consistency)
+}
+
+// WithIdempotent marks queries run with the returned context as safe to retry
+// and to execute speculatively
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// query builds a gocql query bound to ctx with per-call overrides applied
+func (c *client) query(ctx context.Context, stmt string, args ...any) *gocql.Query {
+	q := c.session.Query(stmt, args...).WithContext(ctx)
+	if consistency, ok := ctx.Value(consistencyKey{}).(gocql.Consistency); ok {
+		q.Consistency(consistency)
+	}
+	if idempotent, ok := ctx.Value(idempotentKey{}).(bool); ok {
+		q.Idempotent(idempotent)
+	}
+	if c.speculative != nil {
+		q.SetSpeculativeExecutionPolicy(c.speculative)
+	}
+	return q
+}
+
+// batch builds a gocql batch bound to ctx with per-call overrides applied
+func (c *client) batch(ctx context.Context, b *Batch) *gocql.Batch {
+	batch := b.build(c.session).WithContext(ctx)
+	if consistency, ok := ctx.Value(consistencyKey{}).(gocql.Consistency); ok {
+		batch.SetConsistency(consistency)
+	}
+	if c.speculative != nil {
+		batch.SpeculativeExecutionPolicy(c.speculative)
+	}
+	return batch
+}
@@ -0,0 +1,211 @@
+package cassandra
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// Page is a single page of rows together with the state needed to fetch the next one
+type Page[T any] struct {
+	Items []T
+	// NextPageState is nil when there are no more rows
+	NextPageState []byte
+}
+
+// Iterator streams rows of a query, fetching further pages from the cluster on demand
+type Iterator struct {
+	iter   *gocql.Iter
+	limit  int // Rows to read before stopping, negative means no limit
+	read   int
+	fields map[string][]int
+	typ    reflect.Type
+	err    error
+}
+
+// fieldCache maps struct types to their column -> field index paths
+var fieldCache sync.Map
+
+// Columns returns the column metadata of the result set
+func (it *Iterator) Columns() []gocql.ColumnInfo {
+	return it.iter.Columns()
+}
+
+// Scan reads the next row into dest, a pointer to a struct whose fields are
+// matched to columns by `cql` tag (falling back to the lower-cased field name).
+// Columns without a matching field, and tuple columns, are skipped. Scan
+// returns false when the rows are exhausted or on error; call Close to get the error.
+func (it *Iterator) Scan(dest any) bool {
+	if it.err != nil || (it.limit >= 0 && it.read >= it.limit) {
+		return false
+	}
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Pointer || val.Elem().Kind() != reflect.Struct {
+		it.err = fmt.Errorf("cassandra: scan destination must be a pointer to a struct, got %T", dest)
+		return false
+	}
+	elem := val.Elem()
+	if it.typ != elem.Type() {
+		it.typ = elem.Type()
+		it.fields = structFields(it.typ)
+	}
+
+	targets := make([]any, 0, len(it.iter.Columns()))
+	for _, col := range it.iter.Columns() {
+		// gocql expands tuples into one destination per element; they are
+		// scanned into scratch values since they have no single field to map to
+		if tuple, ok := col.TypeInfo.(gocql.TupleTypeInfo); ok {
+			for _, elemType := range tuple.Elems {
+				targets = append(targets, elemType.New())
+			}
+			continue
+		}
+		var target any
+		if path, ok := it.fields[col.Name]; ok {
+			target = elem.FieldByIndex(path).Addr().Interface()
+		}
+		targets = append(targets, target)
+	}
+	if !it.iter.Scan(targets...) {
+		return false
+	}
+	it.read++
+	return true
+}
+
+// MapScan reads the next row into a map keyed by column name
+func (it *Iterator) MapScan(row map[string]any) bool {
+	if it.err != nil || (it.limit >= 0 && it.read >= it.limit) {
+		return false
+	}
+	if !it.iter.MapScan(row) {
+		return false
+	}
+	it.read++
+	return true
+}
+
+// PageState returns the state to resume after the current page
+func (it *Iterator) PageState() []byte {
+	return it.iter.PageState()
+}
+
+// Close releases the iterator and returns any error seen while reading
+func (it *Iterator) Close() error {
+	closeErr := it.iter.Close()
+	if it.err != nil {
+		return it.err
+	}
+	return closeErr
+}
+
+// Get runs a query and scans the first row into T, returning ErrNotFound when there is none
+func Get[T any](ctx context.Context, c CassandraClient, query string, args ...any) (*T, error) {
+	it := c.Iter(ctx, query, args...)
+	var row T
+	found := it.Scan(&row)
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return &row, nil
+}
+
+// Select runs a query and scans every row into T. Use Iter for large partitions.
+func Select[T any](ctx context.Context, c CassandraClient, query string, args ...any) ([]T, error) {
+	it := c.Iter(ctx, query, args...)
+	results := []T{}
+	for {
+		var row T
+		if !it.Scan(&row) {
+			break
+		}
+		results = append(results, row)
+	}
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SelectPage reads a single page of rows into T starting at pageState (nil for the first page)
+func SelectPage[T any](ctx context.Context, c CassandraClient, pageSize int, pageState []byte, query string, args ...any) (*Page[T], error) {
+	it := c.IterPage(ctx, pageSize, pageState, query, args...)
+	page := &Page[T]{Items: []T{}}
+	for {
+		var row T
+		if !it.Scan(&row) {
+			break
+		}
+		page.Items = append(page.Items, row)
+	}
+	page.NextPageState = it.PageState()
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
+	if len(page.NextPageState) == 0 {
+		page.NextPageState = nil
+	}
+	return page, nil
+}
+
+// EncodePageState turns a page state into an opaque URL-safe cursor for APIs
+func EncodePageState(state []byte) string {
+	return base64.RawURLEncoding.EncodeToString(state)
+}
+
+// DecodePageState parses a cursor produced by EncodePageState
+func DecodePageState(cursor string) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	state, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page cursor: %w", err)
+	}
+	return state, nil
+}
+
+// structFields returns the column name -> field index mapping for a struct type
+func structFields(t reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	collectFields(t, nil, fields)
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// collectFields walks exported fields, descending into embedded structs
+func collectFields(t reflect.Type, parent []int, fields map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := append(append([]int{}, parent...), i)
+		tag := field.Tag.Get("cql")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			collectFields(field.Type, path, fields)
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if _, exists := fields[name]; !exists {
+			fields[name] = path
+		}
+	}
+}
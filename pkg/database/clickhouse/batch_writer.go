@@ -0,0 +1,191 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/rk-the-dev/golib-core/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// BatchWriterConfig configures a BatchWriter
+type BatchWriterConfig struct {
+	// Table is the insert target; rows are mapped to its columns by `ch` struct tags
+	Table         string
+	MaxBatchSize  int           // Flush once this many rows are buffered, defaults to 10000
+	FlushInterval time.Duration // Flush buffered rows at least this often, defaults to 1s
+	MaxRetries    int           // Retries per batch before it is dropped, defaults to 3, negative disables
+	RetryBackoff  time.Duration // Delay before the first retry, doubled on each attempt, defaults to 500ms
+
+	// AsyncInsert lets the server buffer inserts (async_insert=1); WaitForAsyncInsert
+	// makes the flush wait until the server has written the data
+	AsyncInsert        bool
+	WaitForAsyncInsert bool
+
+	// OnError is called when a batch is dropped after all retries
+	OnError func(err error, rows int)
+}
+
+// BatchWriter buffers rows of type T and inserts them with the native batch API
+type BatchWriter[T any] struct {
+	client  ClickHouseClient
+	cfg     BatchWriterConfig
+	query   string
+	buffer  []T
+	mutex   sync.Mutex
+	flushMu sync.Mutex
+	done    chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// NewBatchWriter creates a BatchWriter and starts its interval flusher
+func NewBatchWriter[T any](client ClickHouseClient, cfg BatchWriterConfig) (*BatchWriter[T], error) {
+	if cfg.Table == "" {
+		return nil, fmt.Errorf("batch writer table cannot be empty")
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = 10000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	w := &BatchWriter[T]{
+		client: client,
+		cfg:    cfg,
+		query:  "INSERT INTO " + cfg.Table,
+		buffer: make([]T, 0, cfg.MaxBatchSize),
+		done:   make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.flushLoop()
+	return w, nil
+}
+
+// Write buffers rows, flushing synchronously when the batch size is reached
+func (w *BatchWriter[T]) Write(ctx context.Context, rows ...T) error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return fmt.Errorf("batch writer is closed")
+	}
+	w.buffer = append(w.buffer, rows...)
+	full := len(w.buffer) >= w.cfg.MaxBatchSize
+	w.mutex.Unlock()
+	if full {
+		return w.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush sends all buffered rows, retrying failed batches
+func (w *BatchWriter[T]) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mutex.Lock()
+	rows := w.buffer
+	w.buffer = make([]T, 0, w.cfg.MaxBatchSize)
+	w.mutex.Unlock()
+
+	var firstErr error
+	for start := 0; start < len(rows); start += w.cfg.MaxBatchSize {
+		end := min(start+w.cfg.MaxBatchSize, len(rows))
+		if err := w.sendWithRetry(ctx, rows[start:end]); err != nil {
+			if w.cfg.OnError != nil {
+				w.cfg.OnError(err, end-start)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Close stops the interval flusher and flushes remaining rows
+func (w *BatchWriter[T]) Close(ctx context.Context) error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mutex.Unlock()
+	close(w.done)
+	w.wg.Wait()
+	return w.Flush(ctx)
+}
+
+// flushLoop flushes on every interval tick until Close is called
+func (w *BatchWriter[T]) flushLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			if err := w.Flush(context.Background()); err != nil {
+				logger.Error("ClickHouse batch flush failed", logrus.Fields{"table": w.cfg.Table, "error": err})
+			}
+		}
+	}
+}
+
+// sendWithRetry sends rows as one batch, retrying with exponential backoff
+func (w *BatchWriter[T]) sendWithRetry(ctx context.Context, rows []T) error {
+	backoff := w.cfg.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= w.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = w.send(ctx, rows); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to insert %d rows into %s after %d attempts: %w", len(rows), w.cfg.Table, w.cfg.MaxRetries+1, err)
+}
+
+// send prepares a native batch, appends the rows and sends it
+func (w *BatchWriter[T]) send(ctx context.Context, rows []T) error {
+	if w.cfg.AsyncInsert {
+		settings := ch.Settings{"async_insert": 1, "wait_for_async_insert": 0}
+		if w.cfg.WaitForAsyncInsert {
+			settings["wait_for_async_insert"] = 1
+		}
+		ctx = ch.Context(ctx, ch.WithSettings(settings))
+	}
+	batch, err := w.client.PrepareBatch(ctx, w.query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+	for i := range rows {
+		if err := batch.AppendStruct(&rows[i]); err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("failed to append row: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+	return nil
+}
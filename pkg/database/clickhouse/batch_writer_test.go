@@ -0,0 +1,228 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+type event struct {
+	ID int `ch:"id"`
+}
+
+// fakeClient records the batches sent through PrepareBatch. The first
+// failures sends fail, or every send when failures is negative.
+type fakeClient struct {
+	ClickHouseClient
+	mu       sync.Mutex
+	failures int
+	attempts int
+	batches  [][]int
+	settings []map[string]any
+	sent     chan struct{}
+}
+
+func newFakeClient(failures int) *fakeClient {
+	return &fakeClient{failures: failures, sent: make(chan struct{}, 100)}
+}
+
+func (c *fakeClient) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	c.mu.Lock()
+	c.settings = append(c.settings, contextSettings(ctx))
+	c.mu.Unlock()
+	return &fakeBatch{client: c}, nil
+}
+
+// Batches returns the IDs of every successfully sent batch
+func (c *fakeClient) Batches() [][]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]int(nil), c.batches...)
+}
+
+type fakeBatch struct {
+	driver.Batch
+	client *fakeClient
+	ids    []int
+}
+
+func (b *fakeBatch) AppendStruct(v any) error {
+	b.ids = append(b.ids, v.(*event).ID)
+	return nil
+}
+
+func (b *fakeBatch) Abort() error { return nil }
+
+func (b *fakeBatch) Send() error {
+	c := b.client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	if c.failures < 0 || c.attempts <= c.failures {
+		return errors.New("connection reset")
+	}
+	c.batches = append(c.batches, b.ids)
+	c.sent <- struct{}{}
+	return nil
+}
+
+func events(ids ...int) []event {
+	rows := make([]event, len(ids))
+	for i, id := range ids {
+		rows[i] = event{ID: id}
+	}
+	return rows
+}
+
+func TestBatchWriterFlushesBySize(t *testing.T) {
+	client := newFakeClient(0)
+	w, err := NewBatchWriter[event](client, BatchWriterConfig{Table: "events", MaxBatchSize: 3, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close(context.Background())
+	ctx := context.Background()
+
+	if err := w.Write(ctx, events(1, 2)...); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.Batches(); len(got) != 0 {
+		t.Fatalf("flushed %v before the batch was full", got)
+	}
+	if err := w.Write(ctx, events(3, 4)...); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := client.Batches(), [][]int{{1, 2, 3}, {4}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("batches %v, want %v", got, want)
+	}
+}
+
+func TestBatchWriterFlushesByInterval(t *testing.T) {
+	client := newFakeClient(0)
+	w, err := NewBatchWriter[event](client, BatchWriterConfig{Table: "events", FlushInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close(context.Background())
+
+	if err := w.Write(context.Background(), events(1)...); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-client.sent:
+	case <-time.After(time.Second):
+		t.Fatal("buffered row not flushed on the interval")
+	}
+	if got, want := client.Batches(), [][]int{{1}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("batches %v, want %v", got, want)
+	}
+}
+
+func TestBatchWriterRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		maxRetries   int
+		wantAttempts int
+		wantBatches  int
+		wantDropped  int
+	}{
+		{"succeeds first time", 0, 3, 1, 1, 0},
+		{"succeeds after retries", 2, 3, 3, 1, 0},
+		{"dropped after retries", -1, 2, 3, 0, 2},
+		{"retries disabled", -1, -1, 1, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(tt.failures)
+			dropped := 0
+			w, err := NewBatchWriter[event](client, BatchWriterConfig{
+				Table:         "events",
+				FlushInterval: time.Hour,
+				MaxRetries:    tt.maxRetries,
+				RetryBackoff:  time.Millisecond,
+				OnError:       func(_ error, rows int) { dropped += rows },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close(context.Background())
+			if err := w.Write(context.Background(), events(1, 2)...); err != nil {
+				t.Fatal(err)
+			}
+
+			err = w.Flush(context.Background())
+			if (err != nil) != (tt.wantDropped > 0) {
+				t.Fatalf("Flush returned %v", err)
+			}
+			if client.attempts != tt.wantAttempts || len(client.Batches()) != tt.wantBatches || dropped != tt.wantDropped {
+				t.Errorf("%d attempts, %d batches, %d rows dropped; want %d, %d, %d",
+					client.attempts, len(client.Batches()), dropped, tt.wantAttempts, tt.wantBatches, tt.wantDropped)
+			}
+			// A dropped batch is not sent again
+			if err := w.Flush(context.Background()); err != nil || client.attempts != tt.wantAttempts {
+				t.Errorf("second flush: %v after %d attempts", err, client.attempts)
+			}
+		})
+	}
+}
+
+func TestBatchWriterFlushesOnClose(t *testing.T) {
+	client := newFakeClient(0)
+	w, err := NewBatchWriter[event](client, BatchWriterConfig{Table: "events", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := w.Write(ctx, events(1, 2)...); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := client.Batches(), [][]int{{1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("batches %v, want %v", got, want)
+	}
+	if err := w.Write(ctx, events(3)...); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestBatchWriterAsyncInsert(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  BatchWriterConfig
+		want map[string]any
+	}{
+		{"sync", BatchWriterConfig{}, map[string]any{}},
+		{"async", BatchWriterConfig{AsyncInsert: true}, map[string]any{"async_insert": 1, "wait_for_async_insert": 0}},
+		{"async and wait", BatchWriterConfig{AsyncInsert: true, WaitForAsyncInsert: true}, map[string]any{"async_insert": 1, "wait_for_async_insert": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(0)
+			tt.cfg.Table = "events"
+			w, err := NewBatchWriter[event](client, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(context.Background(), events(1)...); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if len(client.settings) != 1 || !reflect.DeepEqual(client.settings[0], tt.want) {
+				t.Fatalf("settings %v, want %v", client.settings, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,43 @@ package clickhouse
 
 import (
 	"context"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/rk-the-dev/golib-core/pkg/security"
 )
 
+// Config defines ClickHouse connection settings, loadable with config.LoadConfig
+type Config struct {
+	Addrs    []string `env:"CLICKHOUSE_ADDRS" envSeparator:"," envDefault:"localhost:9000"`
+	Database string   `env:"CLICKHOUSE_DATABASE" envDefault:"default"`
+	Username string   `env:"CLICKHOUSE_USERNAME" envDefault:"default"`
+	Password string   `env:"CLICKHOUSE_PASSWORD"`
+
+	// ConnOpenStrategy picks the address for new connections: in_order (failover) or round_robin
+	ConnOpenStrategy string `env:"CLICKHOUSE_CONN_OPEN_STRATEGY" envDefault:"in_order"`
+	// Compression is none, lz4 or zstd
+	Compression      string `env:"CLICKHOUSE_COMPRESSION" envDefault:"lz4"`
+	CompressionLevel int    `env:"CLICKHOUSE_COMPRESSION_LEVEL" envDefault:"0"`
+
+	DialTimeout     time.Duration `env:"CLICKHOUSE_DIAL_TIMEOUT" envDefault:"5s"`
+	ReadTimeout     time.Duration `env:"CLICKHOUSE_READ_TIMEOUT" envDefault:"5m"`
+	MaxOpenConns    int           `env:"CLICKHOUSE_MAX_OPEN_CONNS" envDefault:"10"`
+	MaxIdleConns    int           `env:"CLICKHOUSE_MAX_IDLE_CONNS" envDefault:"5"`
+	ConnMaxLifetime time.Duration `env:"CLICKHOUSE_CONN_MAX_LIFETIME" envDefault:"1h"`
+
+	TLS security.TLSConfig `envPrefix:"CLICKHOUSE_"`
+}
+
 type ClickHouseClient interface {
 	Ping(ctx context.Context) error
+	// Query loads the whole result set; prefer QueryRows, ForEach or Select for large results
 	Query(ctx context.Context, query string, args ...any) ([]map[string]any, error)
+	// QueryRows returns a row iterator that must be closed by the caller
+	QueryRows(ctx context.Context, query string, args ...any) (driver.Rows, error)
+	// QueryEach streams rows as maps, calling fn for each one
+	QueryEach(ctx context.Context, query string, fn func(row map[string]any) error, args ...any) error
 	Exec(ctx context.Context, query string, args ...any) error
+	PrepareBatch(ctx context.Context, query string) (driver.Batch, error)
 	Close() error
 }
@@ -3,34 +3,89 @@ package clickhouse
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/rk-the-dev/golib-core/pkg/security"
 )
 
 type client struct {
 	conn ch.Conn
 }
 
-// New creates a new ClickHouse client using a DSN-like address (e.g., "localhost:9000")
-func New(addr, username, password, database string) (ClickHouseClient, error) {
-	conn, err := ch.Open(&ch.Options{
-		Addr: []string{addr},
-		Auth: ch.Auth{
-			Database: database,
-			Username: username,
-			Password: password,
-		},
-		Settings: ch.Settings{
-			"max_execution_time": 60,
-		},
-	})
+// New creates a ClickHouse client from the given config and verifies it with a Ping
+func New(cfg *Config) (ClickHouseClient, error) {
+	options, err := newOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clickhouse config: %w", err)
+	}
+	conn, err := ch.Open(options)
 	if err != nil {
 		return nil, fmt.Errorf("clickhouse connection failed: %w", err)
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), options.DialTimeout)
+	defer cancel()
+	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("clickhouse ping failed: %w", err)
+	}
 
 	return &client{conn: conn}, nil
 }
 
+// newOptions translates Config into driver options
+func newOptions(cfg *Config) (*ch.Options, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, fmt.Errorf("at least one address is required")
+	}
+	options := &ch.Options{
+		Addr: cfg.Addrs,
+		Auth: ch.Auth{
+			Database: cfg.Database,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		},
+		DialTimeout:     cfg.DialTimeout,
+		ReadTimeout:     cfg.ReadTimeout,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = 5 * time.Second
+	}
+
+	switch strings.ToLower(cfg.ConnOpenStrategy) {
+	case "", "in_order":
+		options.ConnOpenStrategy = ch.ConnOpenInOrder
+	case "round_robin":
+		options.ConnOpenStrategy = ch.ConnOpenRoundRobin
+	case "random":
+		options.ConnOpenStrategy = ch.ConnOpenRandom
+	default:
+		return nil, fmt.Errorf("unknown connection strategy: %s", cfg.ConnOpenStrategy)
+	}
+
+	switch strings.ToLower(cfg.Compression) {
+	case "", "none":
+	case "lz4":
+		options.Compression = &ch.Compression{Method: ch.CompressionLZ4, Level: cfg.CompressionLevel}
+	case "zstd":
+		options.Compression = &ch.Compression{Method: ch.CompressionZSTD, Level: cfg.CompressionLevel}
+	default:
+		return nil, fmt.Errorf("unknown compression: %s", cfg.Compression)
+	}
+
+	tlsConfig, err := security.BuildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	options.TLS = tlsConfig
+	return options, nil
+}
+
 func (c *client) Ping(ctx context.Context) error {
 	return c.conn.Ping(ctx)
 }
@@ -39,30 +94,37 @@ func (c *client) Exec(ctx context.Context, query string, args ...any) error {
 	return c.conn.Exec(ctx, query, args...)
 }
 
+func (c *client) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	return c.conn.PrepareBatch(ctx, query)
+}
+
 func (c *client) Query(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
-	rows, err := c.conn.Query(ctx, query, args...)
+	var results []map[string]any
+	err := c.QueryEach(ctx, query, func(row map[string]any) error {
+		results = append(results, row)
+		return nil
+	}, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return results, nil
+}
 
-	columns := rows.Columns()
-	colCount := len(columns)
+func (c *client) QueryRows(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	return c.conn.Query(ctx, query, args...)
+}
 
-	var results []map[string]any
-	for rows.Next() {
-		values := make([]any, colCount)
-		if err := rows.Scan(values...); err != nil {
-			return nil, err
-		}
-		row := make(map[string]any)
-		for i, col := range columns {
-			row[col] = values[i]
-		}
-		results = append(results, row)
+func (c *client) QueryEach(ctx context.Context, query string, fn func(row map[string]any) error, args ...any) error {
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := c.conn.Query(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return err
 	}
-
-	return results, nil
+	// Cancel before closing so an early exit aborts the query instead of draining it
+	defer rows.Close()
+	defer cancel()
+	return forEachMap(rows, fn)
 }
 
 func (c *client) Close() error {
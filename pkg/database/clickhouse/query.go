@@ -0,0 +1,108 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// QueryOptions holds per-call query settings
+type QueryOptions struct {
+	// QueryID tags the query so it can be found in system.query_log or killed with KILL QUERY
+	QueryID string
+	// MaxExecutionTime bounds the query through the returned context's deadline
+	// and the max_execution_time setting, in whole seconds rounded up
+	MaxExecutionTime time.Duration
+	// Settings are passed through as ClickHouse query settings
+	Settings map[string]any
+}
+
+// WithQueryOptions returns a context carrying the query options. Queries stop
+// server-side when the context is cancelled, so long reports can be aborted.
+// Any settings previously attached to ctx are replaced. The driver derives
+// max_execution_time from any context deadline over 1s, so MaxExecutionTime
+// also sets the deadline; an earlier parent deadline still wins. Call cancel
+// once the query is done.
+func WithQueryOptions(ctx context.Context, opts QueryOptions) (context.Context, context.CancelFunc) {
+	settings := ch.Settings{}
+	for key, value := range opts.Settings {
+		settings[key] = value
+	}
+	cancel := context.CancelFunc(func() {})
+	if opts.MaxExecutionTime > 0 {
+		// Rounding down would turn a sub-second limit into 0, which means no limit
+		settings["max_execution_time"] = int(math.Ceil(opts.MaxExecutionTime.Seconds()))
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxExecutionTime)
+	}
+	chOpts := []ch.QueryOption{ch.WithSettings(settings)}
+	if opts.QueryID != "" {
+		chOpts = append(chOpts, ch.WithQueryID(opts.QueryID))
+	}
+	return ch.Context(ctx, chOpts...), cancel
+}
+
+// ForEach streams the rows of a query into T via `ch` struct tags, calling fn
+// for each row without loading the result set into memory. Returning an error
+// from fn stops the iteration and cancels the query.
+func ForEach[T any](ctx context.Context, c ClickHouseClient, query string, fn func(row T) error, args ...any) error {
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := c.QueryRows(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// Cancel before closing so an early exit aborts the query instead of draining it
+	defer rows.Close()
+	defer cancel()
+	for rows.Next() {
+		var row T
+		if err := rows.ScanStruct(&row); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Select runs a query and scans every row into T via `ch` struct tags
+func Select[T any](ctx context.Context, c ClickHouseClient, query string, args ...any) ([]T, error) {
+	results := []T{}
+	err := ForEach(ctx, c, query, func(row T) error {
+		results = append(results, row)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// forEachMap streams rows as maps keyed by column name
+func forEachMap(rows driver.Rows, fn func(row map[string]any) error) error {
+	columns := rows.Columns()
+	columnTypes := rows.ColumnTypes()
+	for rows.Next() {
+		values := make([]any, len(columns))
+		for i, columnType := range columnTypes {
+			values[i] = reflect.New(columnType.ScanType()).Interface()
+		}
+		if err := rows.Scan(values...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			row[col] = reflect.ValueOf(values[i]).Elem().Interface()
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
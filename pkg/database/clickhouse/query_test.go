@@ -0,0 +1,80 @@
+package clickhouse
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// contextSettings reads back the settings the driver will send with a query
+// made with ctx. They are unexported, so a pass-through option inspects them.
+func contextSettings(ctx context.Context) map[string]any {
+	settings := map[string]any{}
+	ch.Context(ctx, func(o *ch.QueryOptions) error {
+		field := reflect.ValueOf(o).Elem().FieldByName("settings")
+		for _, key := range field.MapKeys() {
+			value := field.MapIndex(key).Elem()
+			switch value.Kind() {
+			case reflect.Int:
+				settings[key.String()] = int(value.Int())
+			case reflect.String:
+				settings[key.String()] = value.String()
+			}
+		}
+		return nil
+	})
+	return settings
+}
+
+func TestWithQueryOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         QueryOptions
+		parent       time.Duration // Parent context timeout, 0 for none
+		wantSettings map[string]any
+		wantDeadline time.Duration // 0 when no deadline is expected
+	}{
+		{"settings only", QueryOptions{Settings: map[string]any{"max_threads": 4}}, 0,
+			map[string]any{"max_threads": 4}, 0},
+		{"sub-second limit rounds up", QueryOptions{MaxExecutionTime: 300 * time.Millisecond}, 0,
+			map[string]any{"max_execution_time": 1}, 300 * time.Millisecond},
+		{"limit sets the deadline", QueryOptions{MaxExecutionTime: 30 * time.Second}, 0,
+			map[string]any{"max_execution_time": 30}, 30 * time.Second},
+		{"earlier parent deadline wins", QueryOptions{MaxExecutionTime: time.Minute}, 10 * time.Second,
+			map[string]any{"max_execution_time": 60}, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := context.Background()
+			if tt.parent > 0 {
+				var cancel context.CancelFunc
+				parent, cancel = context.WithTimeout(parent, tt.parent)
+				defer cancel()
+			}
+			ctx, cancel := WithQueryOptions(parent, tt.opts)
+			defer cancel()
+
+			if got := contextSettings(ctx); !reflect.DeepEqual(got, tt.wantSettings) {
+				t.Errorf("settings %v, want %v", got, tt.wantSettings)
+			}
+			deadline, ok := ctx.Deadline()
+			if ok != (tt.wantDeadline > 0) {
+				t.Fatalf("deadline set %v, want %v", ok, tt.wantDeadline > 0)
+			}
+			if remaining := time.Until(deadline); ok && (remaining > tt.wantDeadline || remaining < tt.wantDeadline-time.Second) {
+				t.Errorf("deadline in %s, want about %s", remaining, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestWithQueryOptionsCancel(t *testing.T) {
+	ctx, cancel := WithQueryOptions(context.Background(), QueryOptions{MaxExecutionTime: time.Minute})
+	cancel()
+	if ctx.Err() == nil {
+		t.Fatal("context not cancelled")
+	}
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResumeTokenStore persists change-stream resume tokens so watchers can resume after restart
+type ResumeTokenStore interface {
+	// Load returns the last saved token for the watcher, or nil when none exists
+	Load(ctx context.Context, watcherName string) (bson.Raw, error)
+	Save(ctx context.Context, watcherName string, token bson.Raw) error
+}
+
+// ChangeEvent is a decoded change-stream event for documents of type T
+type ChangeEvent[T any] struct {
+	ID            bson.Raw            `bson:"_id"`
+	OperationType string              `bson:"operationType"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+	Namespace     struct {
+		Database   string `bson:"db"`
+		Collection string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey       bson.M `bson:"documentKey"`
+	FullDocument      *T     `bson:"fullDocument,omitempty"`
+	UpdateDescription *struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription,omitempty"`
+}
+
+// WatchOptions configures a change-stream watcher
+type WatchOptions struct {
+	// Name identifies the watcher in the ResumeTokenStore and must be stable across restarts
+	Name string
+	// Pipeline filters or reshapes events on the server (e.g. a $match on operationType)
+	Pipeline mongo.Pipeline
+	// FullDocument controls whether updates carry the post-image, defaults to options.UpdateLookup
+	FullDocument options.FullDocument
+	BatchSize    int32
+}
+
+// Watch subscribes to changes on the collection and calls handler for every
+// event. The resume token is saved only after handler succeeds, so delivery is
+// at-least-once across restarts. Watch blocks until ctx is cancelled or an
+// unrecoverable error occurs.
+func (c *Collection[T]) Watch(ctx context.Context, store ResumeTokenStore, opts WatchOptions, handler func(ctx context.Context, event ChangeEvent[T]) error) error {
+	if opts.Name == "" {
+		return fmt.Errorf("watcher name cannot be empty")
+	}
+	if opts.Pipeline == nil {
+		opts.Pipeline = mongo.Pipeline{}
+	}
+	if opts.FullDocument == "" {
+		opts.FullDocument = options.UpdateLookup
+	}
+
+	streamOpts := options.ChangeStream().SetFullDocument(opts.FullDocument)
+	if opts.BatchSize > 0 {
+		streamOpts.SetBatchSize(opts.BatchSize)
+	}
+	if store != nil {
+		token, err := store.Load(ctx, opts.Name)
+		if err != nil {
+			return fmt.Errorf("failed to load resume token: %w", err)
+		}
+		if token != nil {
+			streamOpts.SetStartAfter(token)
+		}
+	}
+
+	stream, err := c.coll.Watch(ctx, opts.Pipeline, streamOpts)
+	if err != nil {
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.Background())
+	logger.Info("Watching MongoDB change stream", logrus.Fields{"collection": c.coll.Name(), "watcher": opts.Name})
+
+	for stream.Next(ctx) {
+		var event ChangeEvent[T]
+		if err := stream.Decode(&event); err != nil {
+			return fmt.Errorf("failed to decode change event: %w", err)
+		}
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("change event handler failed: %w", err)
+		}
+		if store != nil {
+			if err := store.Save(ctx, opts.Name, stream.ResumeToken()); err != nil {
+				return fmt.Errorf("failed to save resume token: %w", err)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("change stream failed: %w", err)
+	}
+	return ctx.Err()
+}
+
+// MemoryResumeTokenStore keeps resume tokens in memory, useful for tests
+type MemoryResumeTokenStore struct {
+	tokens map[string]bson.Raw
+	mutex  sync.Mutex
+}
+
+// NewMemoryResumeTokenStore creates an empty in-memory token store
+func NewMemoryResumeTokenStore() *MemoryResumeTokenStore {
+	return &MemoryResumeTokenStore{tokens: make(map[string]bson.Raw)}
+}
+
+// Load returns the token saved for the watcher
+func (s *MemoryResumeTokenStore) Load(ctx context.Context, watcherName string) (bson.Raw, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.tokens[watcherName], nil
+}
+
+// Save stores a copy of the token for the watcher
+func (s *MemoryResumeTokenStore) Save(ctx context.Context, watcherName string, token bson.Raw) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[watcherName] = append(bson.Raw(nil), token...)
+	return nil
+}
+
+// resumeTokenDocument is the persisted form of a resume token
+type resumeTokenDocument struct {
+	ID        string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// MongoResumeTokenStore persists resume tokens in a MongoDB collection keyed by watcher name
+type MongoResumeTokenStore struct {
+	coll *mongo.Collection
+}
+
+// NewMongoResumeTokenStore stores tokens in the given collection of the client's database
+func NewMongoResumeTokenStore(client MongoDBClient, collectionName string) *MongoResumeTokenStore {
+	return &MongoResumeTokenStore{coll: client.GetCollection(collectionName)}
+}
+
+// Load returns the token saved for the watcher
+func (s *MongoResumeTokenStore) Load(ctx context.Context, watcherName string) (bson.Raw, error) {
+	var doc resumeTokenDocument
+	err := s.coll.FindOne(ctx, bson.M{"_id": watcherName}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Token, nil
+}
+
+// Save upserts the token for the watcher
+func (s *MongoResumeTokenStore) Save(ctx context.Context, watcherName string, token bson.Raw) error {
+	_, err := s.coll.ReplaceOne(ctx,
+		bson.M{"_id": watcherName},
+		resumeTokenDocument{ID: watcherName, Token: token, UpdatedAt: time.Now()},
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
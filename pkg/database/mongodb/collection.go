@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when no document matches a filter
+var ErrNotFound = errors.New("mongodb: document not found")
+
+const defaultPageSize int64 = 20
+
+// Filter is a typed alias for MongoDB query filters
+type Filter = bson.M
+
+// Pagination describes a page of results. Page is 1-based.
+type Pagination struct {
+	Page     int64
+	PageSize int64
+	Sort     bson.D
+}
+
+// Page holds a page of typed documents along with the total match count
+type Page[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int64 `json:"page"`
+	PageSize int64 `json:"page_size"`
+}
+
+// Collection is a typed wrapper around *mongo.Collection decoding documents into T
+type Collection[T any] struct {
+	coll *mongo.Collection
+}
+
+// NewCollection returns a typed collection bound to the client's database
+func NewCollection[T any](client MongoDBClient, collectionName string) *Collection[T] {
+	return &Collection[T]{coll: client.GetCollection(collectionName)}
+}
+
+// Raw returns the underlying *mongo.Collection for operations not covered here
+func (c *Collection[T]) Raw() *mongo.Collection {
+	return c.coll
+}
+
+// FindOne returns the first document matching the filter, or ErrNotFound
+func (c *Collection[T]) FindOne(ctx context.Context, filter Filter, opts ...*options.FindOneOptions) (*T, error) {
+	var doc T
+	err := c.coll.FindOne(ctx, normalizeFilter(filter), opts...).Decode(&doc)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &doc, nil
+}
+
+// Find returns all documents matching the filter
+func (c *Collection[T]) Find(ctx context.Context, filter Filter, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := c.coll.Find(ctx, normalizeFilter(filter), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents: %w", err)
+	}
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %w", err)
+	}
+	return results, nil
+}
+
+// FindPage returns a single page of documents matching the filter along with the total count
+func (c *Collection[T]) FindPage(ctx context.Context, filter Filter, page Pagination) (*Page[T], error) {
+	if page.Page < 1 {
+		page.Page = 1
+	}
+	if page.PageSize < 1 {
+		page.PageSize = defaultPageSize
+	}
+	filter = normalizeFilter(filter)
+	total, err := c.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+	findOpts := options.Find().
+		SetSkip((page.Page - 1) * page.PageSize).
+		SetLimit(page.PageSize)
+	if len(page.Sort) > 0 {
+		findOpts.SetSort(page.Sort)
+	}
+	items, err := c.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &Page[T]{Items: items, Total: total, Page: page.Page, PageSize: page.PageSize}, nil
+}
+
+// Count returns the number of documents matching the filter
+func (c *Collection[T]) Count(ctx context.Context, filter Filter) (int64, error) {
+	count, err := c.coll.CountDocuments(ctx, normalizeFilter(filter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return count, nil
+}
+
+// InsertOne inserts a document and returns its _id
+func (c *Collection[T]) InsertOne(ctx context.Context, doc T) (interface{}, error) {
+	res, err := c.coll.InsertOne(ctx, doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert document: %w", err)
+	}
+	return res.InsertedID, nil
+}
+
+// InsertMany inserts documents and returns their _ids in order
+func (c *Collection[T]) InsertMany(ctx context.Context, docs []T) ([]interface{}, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	items := make([]interface{}, len(docs))
+	for i := range docs {
+		items[i] = docs[i]
+	}
+	res, err := c.coll.InsertMany(ctx, items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert documents: %w", err)
+	}
+	return res.InsertedIDs, nil
+}
+
+// UpdateOne applies an update document to the first match. Without upsert,
+// ErrNotFound is returned when nothing matched.
+func (c *Collection[T]) UpdateOne(ctx context.Context, filter Filter, update interface{}, upsert bool) (*mongo.UpdateResult, error) {
+	res, err := c.coll.UpdateOne(ctx, normalizeFilter(filter), update, options.Update().SetUpsert(upsert))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+	if !upsert && res.MatchedCount == 0 {
+		return res, ErrNotFound
+	}
+	return res, nil
+}
+
+// DeleteOne removes the first match, or returns ErrNotFound
+func (c *Collection[T]) DeleteOne(ctx context.Context, filter Filter) error {
+	res, err := c.coll.DeleteOne(ctx, normalizeFilter(filter))
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Aggregate runs a pipeline and decodes the output as T
+func (c *Collection[T]) Aggregate(ctx context.Context, pipeline mongo.Pipeline, opts ...*options.AggregateOptions) ([]T, error) {
+	return Aggregate[T](ctx, c.coll, pipeline, opts...)
+}
+
+// Aggregate runs a pipeline on a collection and decodes the output as R,
+// for pipelines whose result shape differs from the stored documents
+func Aggregate[R any](ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, opts ...*options.AggregateOptions) ([]R, error) {
+	cursor, err := coll.Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run aggregation: %w", err)
+	}
+	results := []R{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode aggregation results: %w", err)
+	}
+	return results, nil
+}
+
+// normalizeFilter turns a nil filter into an empty document, which the driver requires
+func normalizeFilter(filter Filter) Filter {
+	if filter == nil {
+		return Filter{}
+	}
+	return filter
+}
+
+// mapError converts driver errors into package errors
+func mapError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -3,28 +3,61 @@ package database
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/rk-the-dev/golib-core/pkg/logger"
+	"github.com/rk-the-dev/golib-core/pkg/security"
 	"github.com/sirupsen/logrus"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 // MongoDBConfig holds the configuration for MongoDB connection
 type MongoDBConfig struct {
-	URI      string
-	Username string
-	Password string
-	Database string
-	Timeout  time.Duration
+	URI      string        `env:"MONGO_URI" envDefault:"mongodb://localhost:27017"`
+	Username string        `env:"MONGO_USERNAME"`
+	Password string        `env:"MONGO_PASSWORD"`
+	Database string        `env:"MONGO_DATABASE" envDefault:"mydb"`
+	Timeout  time.Duration `env:"MONGO_TIMEOUT" envDefault:"10s"` // Connect and initial ping timeout
+	AppName  string        `env:"MONGO_APP_NAME"`
+
+	// Connection pool
+	MinPoolSize     uint64        `env:"MONGO_MIN_POOL_SIZE" envDefault:"0"`
+	MaxPoolSize     uint64        `env:"MONGO_MAX_POOL_SIZE" envDefault:"100"`
+	MaxConnIdleTime time.Duration `env:"MONGO_MAX_CONN_IDLE_TIME" envDefault:"5m"`
+
+	// Server selection and socket timeouts
+	ServerSelectionTimeout time.Duration `env:"MONGO_SERVER_SELECTION_TIMEOUT" envDefault:"30s"`
+	SocketTimeout          time.Duration `env:"MONGO_SOCKET_TIMEOUT" envDefault:"0s"`
+
+	// ReadPreference is one of primary, primaryPreferred, secondary, secondaryPreferred, nearest
+	ReadPreference string `env:"MONGO_READ_PREFERENCE" envDefault:"primary"`
+	// ReadConcern is one of local, available, majority, linearizable, snapshot (empty uses the server default)
+	ReadConcern string `env:"MONGO_READ_CONCERN"`
+	// WriteConcern is "majority", a tag set name or a node count such as "1" (empty uses the server default)
+	WriteConcern        string        `env:"MONGO_WRITE_CONCERN"`
+	WriteConcernJournal bool          `env:"MONGO_WRITE_CONCERN_JOURNAL" envDefault:"false"`
+	WriteConcernTimeout time.Duration `env:"MONGO_WRITE_CONCERN_TIMEOUT" envDefault:"0s"`
+
+	// Compressors lists wire compressors in order of preference: snappy, zlib, zstd
+	Compressors []string `env:"MONGO_COMPRESSORS" envSeparator:","`
+	// Retryable reads and writes follow the URI or the driver default (on); these flags force them off
+	DisableRetryWrites bool `env:"MONGO_DISABLE_RETRY_WRITES" envDefault:"false"`
+	DisableRetryReads  bool `env:"MONGO_DISABLE_RETRY_READS" envDefault:"false"`
+
+	TLS security.TLSConfig `envPrefix:"MONGO_"`
 }
 
 // MongoDBClient defines the interface for MongoDB operations
 type MongoDBClient interface {
 	GetCollection(collectionName string) *mongo.Collection
+	WithTransaction(ctx context.Context, txFunc func(sessCtx mongo.SessionContext) error) error
 	Close() error
 }
 
@@ -34,44 +67,136 @@ type mongoDBClientImpl struct {
 	database *mongo.Database
 }
 
+const defaultTimeout = 10 * time.Second
+
 var (
 	instance MongoDBClient
-	once     sync.Once
+	mu       sync.Mutex
 )
 
-// GetMongoDBClient returns a singleton MongoDB client instance
+// GetMongoDBClient returns a singleton MongoDB client instance. The connection
+// is verified with a Ping so a misconfigured client fails here rather than on
+// first use. A failed attempt is not cached, so a later call retries.
 func GetMongoDBClient(cfg MongoDBConfig) (MongoDBClient, error) {
-	var err error
-	once.Do(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
-		defer cancel()
-		clientOptions := options.Client().ApplyURI(cfg.URI)
-		if cfg.Username != "" && cfg.Password != "" {
-			clientOptions.SetAuth(options.Credential{
-				Username: cfg.Username,
-				Password: cfg.Password,
-			})
+	mu.Lock()
+	defer mu.Unlock()
+	if instance != nil {
+		return instance, nil
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	clientOptions, err := buildClientOptions(cfg)
+	if err != nil {
+		logger.Error("MongoDB configuration invalid", logrus.Fields{"error": err})
+		return nil, fmt.Errorf("invalid MongoDB configuration: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	logger.Info("Connecting to MongoDB", logrus.Fields{"database": cfg.Database, "app_name": cfg.AppName})
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		logger.Error("MongoDB connection failed", logrus.Fields{"error": err})
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	if err := client.Ping(ctx, clientOptions.ReadPreference); err != nil {
+		_ = client.Disconnect(context.Background())
+		logger.Error("MongoDB ping failed", logrus.Fields{"error": err})
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+	instance = &mongoDBClientImpl{
+		client:   client,
+		database: client.Database(cfg.Database),
+	}
+	logger.Info("Connected to MongoDB successfully", logrus.Fields{"database": cfg.Database})
+	return instance, nil
+}
+
+// buildClientOptions translates MongoDBConfig into driver options
+func buildClientOptions(cfg MongoDBConfig) (*options.ClientOptions, error) {
+	clientOptions := options.Client().ApplyURI(cfg.URI)
+	if cfg.Username != "" && cfg.Password != "" {
+		clientOptions.SetAuth(options.Credential{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		})
+	}
+	if cfg.AppName != "" {
+		clientOptions.SetAppName(cfg.AppName)
+	}
+	if cfg.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(cfg.MinPoolSize)
+	}
+	if cfg.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+	if cfg.Timeout > 0 {
+		clientOptions.SetConnectTimeout(cfg.Timeout)
+	}
+	if cfg.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	}
+	if cfg.SocketTimeout > 0 {
+		clientOptions.SetSocketTimeout(cfg.SocketTimeout)
+	}
+	if len(cfg.Compressors) > 0 {
+		clientOptions.SetCompressors(cfg.Compressors)
+	}
+	if cfg.DisableRetryWrites {
+		clientOptions.SetRetryWrites(false)
+	}
+	if cfg.DisableRetryReads {
+		clientOptions.SetRetryReads(false)
+	}
+
+	if cfg.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(cfg.ReadPreference)
+		if err != nil {
+			return nil, err
 		}
-		logger.Info("Connecting to MongoDB", logrus.Fields{"uri": cfg.URI, "database": cfg.Database})
-		client, connErr := mongo.Connect(ctx, clientOptions)
-		if connErr != nil {
-			err = fmt.Errorf("failed to connect to MongoDB: %w", connErr)
-			logger.Error("MongoDB connection failed", logrus.Fields{"error": connErr})
-			return
+		pref, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
 		}
-		db := client.Database(cfg.Database)
-		instance = &mongoDBClientImpl{
-			client:   client,
-			database: db,
+		clientOptions.SetReadPreference(pref)
+	}
+	if cfg.ReadConcern != "" {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: cfg.ReadConcern})
+	}
+	if cfg.WriteConcern != "" || cfg.WriteConcernJournal || cfg.WriteConcernTimeout > 0 {
+		wc := &writeconcern.WriteConcern{WTimeout: cfg.WriteConcernTimeout}
+		if cfg.WriteConcern != "" {
+			if n, convErr := strconv.Atoi(cfg.WriteConcern); convErr == nil {
+				wc.W = n
+			} else {
+				wc.W = cfg.WriteConcern
+			}
 		}
-		logger.Info("Connected to MongoDB successfully", logrus.Fields{"database": cfg.Database})
-	})
-	return instance, err
+		if cfg.WriteConcernJournal {
+			journal := true
+			wc.Journal = &journal
+		}
+		clientOptions.SetWriteConcern(wc)
+	}
+
+	tlsConfig, err := security.BuildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+	return clientOptions, clientOptions.Validate()
 }
 
 // GetCollection returns a MongoDB collection
 func (m *mongoDBClientImpl) GetCollection(collectionName string) *mongo.Collection {
-	logger.Info("Fetching collection", logrus.Fields{"collection": collectionName})
+	logger.Debug("Fetching collection", logrus.Fields{"collection": collectionName})
 	return m.database.Collection(collectionName)
 }
 
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBuildClientOptionsRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        MongoDBConfig
+		wantWrites string
+		wantReads  string
+	}{
+		{"driver default", MongoDBConfig{URI: "mongodb://localhost:27017"}, "unset", "unset"},
+		{"URI settings kept", MongoDBConfig{URI: "mongodb://localhost:27017/?retryWrites=false&retryReads=true"}, "false", "true"},
+		{"flags override URI", MongoDBConfig{
+			URI:                "mongodb://localhost:27017/?retryWrites=true&retryReads=true",
+			DisableRetryWrites: true,
+			DisableRetryReads:  true,
+		}, "false", "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := buildClientOptions(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if writes, reads := show(opts.RetryWrites), show(opts.RetryReads); writes != tt.wantWrites || reads != tt.wantReads {
+				t.Fatalf("retryWrites %s retryReads %s, want %s %s", writes, reads, tt.wantWrites, tt.wantReads)
+			}
+		})
+	}
+}
+
+// show formats an optional driver flag
+func show(b *bool) string {
+	if b == nil {
+		return "unset"
+	}
+	return strconv.FormatBool(*b)
+}
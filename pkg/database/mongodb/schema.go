@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rk-the-dev/golib-core/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexSpec declares an index. Compound indexes list several keys in order.
+type IndexSpec struct {
+	Name          string // Defaults to the driver naming scheme, e.g. "email_1_created_at_-1"
+	Keys          bson.D // e.g. bson.D{{Key: "email", Value: 1}}
+	Unique        bool   // Reject duplicate keys
+	Sparse        bool   // Skip documents missing the indexed fields
+	TTL           *int32 // Expire documents this many seconds after the indexed date field, nil disables and 0 expires at that date
+	PartialFilter bson.D // Only index documents matching this filter
+}
+
+// CollectionSchema declares the managed indexes and JSON-schema validator of a collection
+type CollectionSchema struct {
+	Collection       string
+	Indexes          []IndexSpec
+	Validator        bson.M // Usually bson.M{"$jsonSchema": ...}, nil leaves validation untouched
+	ValidationLevel  string // "strict" (default) or "moderate"
+	ValidationAction string // "error" (default) or "warn"
+}
+
+// IndexDiff reports how the existing indexes of a collection differ from its declaration
+type IndexDiff struct {
+	Collection string
+	Missing    []IndexSpec // Declared but not present
+	Changed    []IndexSpec // Present under the same name with different keys or options
+	Unmanaged  []string    // Present but not declared (the _id index is ignored)
+}
+
+// HasChanges reports whether the diff contains any difference
+func (d IndexDiff) HasChanges() bool {
+	return len(d.Missing) > 0 || len(d.Changed) > 0 || len(d.Unmanaged) > 0
+}
+
+// ApplyOptions controls how ApplySchemas reconciles indexes
+type ApplyOptions struct {
+	DryRun          bool // Only compute diffs, change nothing
+	RecreateChanged bool // Drop and recreate declared indexes whose definition changed
+	DropUnmanaged   bool // Drop indexes that are not declared
+}
+
+// existingIndex is the subset of listIndexes output used for diffing
+type existingIndex struct {
+	Name                    string `bson:"name"`
+	Key                     bson.D `bson:"key"`
+	Unique                  bool   `bson:"unique"`
+	Sparse                  bool   `bson:"sparse"`
+	ExpireAfterSeconds      *int64 `bson:"expireAfterSeconds"`
+	PartialFilterExpression bson.D `bson:"partialFilterExpression"`
+	Weights                 bson.D `bson:"weights"`
+}
+
+// ApplySchemas idempotently creates collections, validators and indexes for the
+// given declarations and returns the diff computed before any change was made.
+// Unmanaged indexes are only dropped when opts.DropUnmanaged is set.
+func ApplySchemas(ctx context.Context, client MongoDBClient, schemas []CollectionSchema, opts ApplyOptions) ([]IndexDiff, error) {
+	diffs := make([]IndexDiff, 0, len(schemas))
+	for _, schema := range schemas {
+		coll := client.GetCollection(schema.Collection)
+		if !opts.DryRun {
+			if err := applyValidator(ctx, coll.Database(), schema); err != nil {
+				return diffs, err
+			}
+		}
+		diff, err := diffIndexes(ctx, coll, schema)
+		if err != nil {
+			return diffs, err
+		}
+		diffs = append(diffs, diff)
+		if diff.HasChanges() {
+			logger.Info("MongoDB index diff", logrus.Fields{
+				"collection": diff.Collection,
+				"missing":    indexNames(diff.Missing),
+				"changed":    indexNames(diff.Changed),
+				"unmanaged":  diff.Unmanaged,
+				"dry_run":    opts.DryRun,
+			})
+		}
+		if opts.DryRun {
+			continue
+		}
+		if err := reconcileIndexes(ctx, coll, diff, opts); err != nil {
+			return diffs, err
+		}
+	}
+	return diffs, nil
+}
+
+// DiffIndexes compares the declared indexes of a collection with the existing ones
+func DiffIndexes(ctx context.Context, client MongoDBClient, schema CollectionSchema) (IndexDiff, error) {
+	return diffIndexes(ctx, client.GetCollection(schema.Collection), schema)
+}
+
+// diffIndexes lists the collection's indexes and compares them by name with the declaration
+func diffIndexes(ctx context.Context, coll *mongo.Collection, schema CollectionSchema) (IndexDiff, error) {
+	diff := IndexDiff{Collection: schema.Collection}
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return diff, fmt.Errorf("failed to list indexes for %s: %w", schema.Collection, err)
+	}
+	var existing []existingIndex
+	if err := cursor.All(ctx, &existing); err != nil {
+		return diff, fmt.Errorf("failed to decode indexes for %s: %w", schema.Collection, err)
+	}
+
+	existingByName := make(map[string]existingIndex, len(existing))
+	for _, idx := range existing {
+		existingByName[idx.Name] = idx
+	}
+	declared := make(map[string]bool, len(schema.Indexes))
+	for _, spec := range schema.Indexes {
+		spec.Name = specName(spec)
+		declared[spec.Name] = true
+		current, ok := existingByName[spec.Name]
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, spec)
+		case !indexMatches(spec, current):
+			diff.Changed = append(diff.Changed, spec)
+		}
+	}
+	for _, idx := range existing {
+		if idx.Name != "_id_" && !declared[idx.Name] {
+			diff.Unmanaged = append(diff.Unmanaged, idx.Name)
+		}
+	}
+	return diff, nil
+}
+
+// reconcileIndexes applies a diff according to the options
+func reconcileIndexes(ctx context.Context, coll *mongo.Collection, diff IndexDiff, opts ApplyOptions) error {
+	toCreate := append([]IndexSpec{}, diff.Missing...)
+	if opts.RecreateChanged {
+		for _, spec := range diff.Changed {
+			if _, err := coll.Indexes().DropOne(ctx, spec.Name); err != nil {
+				return fmt.Errorf("failed to drop changed index %s.%s: %w", diff.Collection, spec.Name, err)
+			}
+			toCreate = append(toCreate, spec)
+		}
+	}
+	if opts.DropUnmanaged {
+		for _, name := range diff.Unmanaged {
+			if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+				return fmt.Errorf("failed to drop unmanaged index %s.%s: %w", diff.Collection, name, err)
+			}
+		}
+	}
+	if len(toCreate) == 0 {
+		return nil
+	}
+	models := make([]mongo.IndexModel, 0, len(toCreate))
+	for _, spec := range toCreate {
+		models = append(models, indexModel(spec))
+	}
+	if _, err := coll.Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create indexes on %s: %w", diff.Collection, err)
+	}
+	return nil
+}
+
+// applyValidator creates the collection with its validator, or updates the validator via collMod
+func applyValidator(ctx context.Context, db *mongo.Database, schema CollectionSchema) error {
+	if schema.Validator == nil {
+		return nil
+	}
+	level := schema.ValidationLevel
+	if level == "" {
+		level = "strict"
+	}
+	action := schema.ValidationAction
+	if action == "" {
+		action = "error"
+	}
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": schema.Collection})
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	if len(names) == 0 {
+		err = db.CreateCollection(ctx, schema.Collection, options.CreateCollection().
+			SetValidator(schema.Validator).
+			SetValidationLevel(level).
+			SetValidationAction(action))
+		if err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", schema.Collection, err)
+		}
+		return nil
+	}
+	err = db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: schema.Collection},
+		{Key: "validator", Value: schema.Validator},
+		{Key: "validationLevel", Value: level},
+		{Key: "validationAction", Value: action},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to update validator on %s: %w", schema.Collection, err)
+	}
+	return nil
+}
+
+// indexModel converts a spec into a driver index model
+func indexModel(spec IndexSpec) mongo.IndexModel {
+	opts := options.Index().SetName(specName(spec))
+	if spec.Unique {
+		opts.SetUnique(true)
+	}
+	if spec.Sparse {
+		opts.SetSparse(true)
+	}
+	if spec.TTL != nil {
+		opts.SetExpireAfterSeconds(*spec.TTL)
+	}
+	if len(spec.PartialFilter) > 0 {
+		opts.SetPartialFilterExpression(spec.PartialFilter)
+	}
+	return mongo.IndexModel{Keys: spec.Keys, Options: opts}
+}
+
+// indexMatches compares a declared index with an existing one
+func indexMatches(spec IndexSpec, current existingIndex) bool {
+	if spec.Unique != current.Unique || spec.Sparse != current.Sparse {
+		return false
+	}
+	if (spec.TTL == nil) != (current.ExpireAfterSeconds == nil) ||
+		spec.TTL != nil && int64(*spec.TTL) != *current.ExpireAfterSeconds {
+		return false
+	}
+	keys, textFields := textKeys(spec.Keys)
+	if len(keys) != len(current.Key) {
+		return false
+	}
+	for i, key := range keys {
+		if key.Key != current.Key[i].Key || !reflect.DeepEqual(normalizeValue(key.Value), normalizeValue(current.Key[i].Value)) {
+			return false
+		}
+	}
+	if len(textFields) > 0 {
+		weighted := make([]string, 0, len(current.Weights))
+		for _, w := range current.Weights {
+			weighted = append(weighted, w.Key)
+		}
+		sort.Strings(weighted)
+		if !reflect.DeepEqual(textFields, weighted) {
+			return false
+		}
+	}
+	return reflect.DeepEqual(normalizeValue(spec.PartialFilter), normalizeValue(current.PartialFilterExpression))
+}
+
+// textKeys replaces the text fields of declared keys with the _fts/_ftsx pair
+// the server lists for text indexes, and returns those fields sorted
+func textKeys(keys bson.D) (bson.D, []string) {
+	out := make(bson.D, 0, len(keys))
+	var fields []string
+	for _, key := range keys {
+		if key.Value != "text" {
+			out = append(out, key)
+			continue
+		}
+		if fields == nil {
+			out = append(out, bson.E{Key: "_fts", Value: "text"}, bson.E{Key: "_ftsx", Value: 1})
+		}
+		fields = append(fields, key.Key)
+	}
+	sort.Strings(fields)
+	return out, fields
+}
+
+// normalizeValue maps numbers to float64 and documents to maps so that
+// declared values (int, bson.M) compare equal to server values (int32, bson.D)
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return float64(val)
+	case int32:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case float32:
+		return float64(val)
+	case bson.D:
+		if len(val) == 0 {
+			return nil
+		}
+		out := make(map[string]interface{}, len(val))
+		for _, e := range val {
+			out[e.Key] = normalizeValue(e.Value)
+		}
+		return out
+	case bson.M:
+		if len(val) == 0 {
+			return nil
+		}
+		out := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			out[k] = normalizeValue(e)
+		}
+		return out
+	case bson.A:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = normalizeValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// specName returns the declared name or the driver's default name for the keys
+func specName(spec IndexSpec) string {
+	if spec.Name != "" {
+		return spec.Name
+	}
+	parts := make([]string, 0, len(spec.Keys)*2)
+	for _, key := range spec.Keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// indexNames extracts the names of the given specs for logging
+func indexNames(specs []IndexSpec) []string {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestIndexMatches(t *testing.T) {
+	zero, day := int32(0), int32(86400)
+	serverZero, serverDay := int64(0), int64(86400)
+	textKey := bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}
+	tests := []struct {
+		name    string
+		spec    IndexSpec
+		current existingIndex
+		want    bool
+	}{
+		{"same keys", IndexSpec{Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+			existingIndex{Key: bson.D{{Key: "email", Value: int32(1)}}, Unique: true}, true},
+		{"different direction", IndexSpec{Keys: bson.D{{Key: "email", Value: 1}}},
+			existingIndex{Key: bson.D{{Key: "email", Value: int32(-1)}}}, false},
+		{"no TTL", IndexSpec{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+			existingIndex{Key: bson.D{{Key: "expires_at", Value: int32(1)}}}, true},
+		{"zero TTL", IndexSpec{Keys: bson.D{{Key: "expires_at", Value: 1}}, TTL: &zero},
+			existingIndex{Key: bson.D{{Key: "expires_at", Value: int32(1)}}, ExpireAfterSeconds: &serverZero}, true},
+		{"zero TTL missing on server", IndexSpec{Keys: bson.D{{Key: "expires_at", Value: 1}}, TTL: &zero},
+			existingIndex{Key: bson.D{{Key: "expires_at", Value: int32(1)}}}, false},
+		{"TTL removed", IndexSpec{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+			existingIndex{Key: bson.D{{Key: "expires_at", Value: int32(1)}}, ExpireAfterSeconds: &serverZero}, false},
+		{"TTL changed", IndexSpec{Keys: bson.D{{Key: "created_at", Value: 1}}, TTL: &day},
+			existingIndex{Key: bson.D{{Key: "created_at", Value: int32(1)}}, ExpireAfterSeconds: &serverZero}, false},
+		{"same TTL", IndexSpec{Keys: bson.D{{Key: "created_at", Value: 1}}, TTL: &day},
+			existingIndex{Key: bson.D{{Key: "created_at", Value: int32(1)}}, ExpireAfterSeconds: &serverDay}, true},
+		{"text index", IndexSpec{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "body", Value: "text"}}},
+			existingIndex{Key: textKey, Weights: bson.D{{Key: "body", Value: int32(1)}, {Key: "title", Value: int32(1)}}}, true},
+		{"text index with other fields", IndexSpec{Keys: bson.D{{Key: "title", Value: "text"}}},
+			existingIndex{Key: textKey, Weights: bson.D{{Key: "body", Value: int32(1)}}}, false},
+		{"compound text index", IndexSpec{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "title", Value: "text"}}},
+			existingIndex{Key: append(bson.D{{Key: "tenant", Value: int32(1)}}, textKey...), Weights: bson.D{{Key: "title", Value: int32(1)}}}, true},
+		{"same partial filter", IndexSpec{Keys: bson.D{{Key: "email", Value: 1}}, PartialFilter: bson.D{{Key: "active", Value: true}}},
+			existingIndex{Key: bson.D{{Key: "email", Value: int32(1)}}, PartialFilterExpression: bson.D{{Key: "active", Value: true}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexMatches(tt.spec, tt.current); got != tt.want {
+				t.Fatalf("indexMatches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// transactionSession is the part of mongo.Session used to run transactions
+type transactionSession interface {
+	WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) (interface{}, error), opts ...*options.TransactionOptions) (interface{}, error)
+	EndSession(ctx context.Context)
+}
+
+// WithTransaction runs txFunc inside a multi-document transaction. The driver
+// retries the whole transaction on TransientTransactionError and the commit on
+// UnknownTransactionCommitResult for up to 120s, so txFunc must be safe to re-run.
+func (m *mongoDBClientImpl) WithTransaction(ctx context.Context, txFunc func(sessCtx mongo.SessionContext) error) error {
+	session, err := m.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	return runTransaction(ctx, session, txFunc)
+}
+
+// runTransaction runs txFunc in a snapshot transaction with majority writes and ends the session
+func runTransaction(ctx context.Context, session transactionSession, txFunc func(sessCtx mongo.SessionContext) error) error {
+	defer session.EndSession(ctx)
+
+	txnOpts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+
+	_, err := session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, txFunc(sessCtx)
+	}, txnOpts)
+	if err != nil {
+		return fmt.Errorf("transaction rolled back due to error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeSession stands in for a driver session. Like the driver, it re-runs
+// the callback while it fails with TransientTransactionError.
+type fakeSession struct {
+	mongo.Session
+	calls int
+	ended bool
+	opts  []*options.TransactionOptions
+}
+
+func (s *fakeSession) WithTransaction(ctx context.Context, fn func(mongo.SessionContext) (interface{}, error), opts ...*options.TransactionOptions) (interface{}, error) {
+	s.calls++
+	s.opts = opts
+	for {
+		res, err := fn(mongo.NewSessionContext(ctx, s))
+		var labeled mongo.LabeledError
+		if errors.As(err, &labeled) && labeled.HasErrorLabel("TransientTransactionError") {
+			continue
+		}
+		return res, err
+	}
+}
+
+func (s *fakeSession) EndSession(context.Context) {
+	s.ended = true
+}
+
+func TestRunTransaction(t *testing.T) {
+	failure := errors.New("duplicate key")
+	transient := mongo.CommandError{Message: "write conflict", Labels: []string{"TransientTransactionError"}}
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive txFunc runs
+		wantRuns  int
+		wantError error
+	}{
+		{"commits", []error{nil}, 1, nil},
+		{"returns callback error", []error{failure}, 1, failure},
+		{"driver retries transient errors", []error{transient, transient, nil}, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &fakeSession{}
+			runs := 0
+			err := runTransaction(context.Background(), session, func(sessCtx mongo.SessionContext) error {
+				if mongo.SessionFromContext(sessCtx) != session {
+					t.Error("txFunc not bound to the session")
+				}
+				err := tt.errs[runs]
+				runs++
+				return err
+			})
+			if tt.wantError == nil && err != nil || tt.wantError != nil && !errors.Is(err, tt.wantError) {
+				t.Fatalf("got error %v, want %v", err, tt.wantError)
+			}
+			if runs != tt.wantRuns || session.calls != 1 {
+				t.Errorf("txFunc ran %d times in %d session transactions, want %d in 1", runs, session.calls, tt.wantRuns)
+			}
+			if !session.ended {
+				t.Error("session not ended")
+			}
+			if len(session.opts) != 1 || session.opts[0].ReadConcern.Level != "snapshot" || session.opts[0].WriteConcern.W != "majority" {
+				t.Errorf("unexpected transaction options %+v", session.opts)
+			}
+		})
+	}
+}
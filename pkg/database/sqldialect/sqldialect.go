@@ -0,0 +1,105 @@
+package sqldialect
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect selects the SQL flavour of generated statements
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+)
+
+// Executor runs queries. The DBClient of postgresquery, mysqlquery and
+// sqlitequery all implement it.
+type Executor interface {
+	ExecuteQuery(query string, args ...interface{}) (*sql.Rows, error)
+	ExecuteNonQuery(query string, args ...interface{}) (sql.Result, error)
+}
+
+// TxRunner runs a function inside a transaction. The DBClient of
+// postgresquery, mysqlquery and sqlitequery all implement it.
+type TxRunner interface {
+	WithTransaction(txFunc func(*sql.Tx) error) error
+}
+
+// Validate returns an error for an unsupported dialect
+func (d Dialect) Validate() error {
+	switch d {
+	case Postgres, MySQL, SQLite:
+		return nil
+	}
+	return fmt.Errorf("unsupported SQL dialect: %q", d)
+}
+
+// Param returns the bind parameter at position i
+func (d Dialect) Param(i int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(i)
+	}
+	return "?"
+}
+
+// Params returns n comma-separated bind parameters starting at position start
+func (d Dialect) Params(start, n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = d.Param(start + i)
+	}
+	return strings.Join(params, ", ")
+}
+
+// Timestamp returns the column type for a timestamp with sub-second precision
+func (d Dialect) Timestamp() string {
+	switch d {
+	case MySQL:
+		return "DATETIME(6)"
+	case SQLite:
+		return "TIMESTAMP"
+	}
+	return "TIMESTAMPTZ"
+}
+
+// InsertIgnore returns an insert of columns into table that does nothing when
+// a row with the same key column already exists
+func (d Dialect) InsertIgnore(table, key string, columns ...string) string {
+	values := " (" + strings.Join(columns, ", ") + ") VALUES (" + d.Params(1, len(columns)) + ")"
+	if d == MySQL {
+		return "INSERT IGNORE INTO " + table + values
+	}
+	return "INSERT INTO " + table + values + " ON CONFLICT (" + key + ") DO NOTHING"
+}
+
+// SkipLocked returns the row locking suffix for claiming rows that other
+// transactions have not locked. SQLite serializes writers, so it needs none.
+func (d Dialect) SkipLocked() string {
+	if d == SQLite {
+		return ""
+	}
+	return " FOR UPDATE SKIP LOCKED"
+}
+
+// EnsureTable runs a CREATE TABLE IF NOT EXISTS statement
+func EnsureTable(db Executor, schema string) error {
+	_, err := db.ExecuteNonQuery(schema)
+	return err
+}
+
+// Affected runs a statement and reports whether it changed a row
+func Affected(db Executor, query string, args ...interface{}) (bool, error) {
+	result, err := db.ExecuteNonQuery(query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
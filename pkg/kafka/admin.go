@@ -0,0 +1,254 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TopicConfig describes a topic to create
+type TopicConfig struct {
+	Name              string
+	Partitions        int               // Defaults to 1
+	ReplicationFactor int               // Defaults to 1
+	Configs           map[string]string // Topic-level configs, e.g. retention.ms
+}
+
+// TopicInfo describes an existing topic
+type TopicInfo struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	Internal          bool
+}
+
+// GroupMember is a member of a consumer group and its assigned partitions
+type GroupMember struct {
+	MemberID    string
+	ClientID    string
+	ClientHost  string
+	Assignments map[string][]int // Topic -> partitions
+}
+
+// GroupDescription describes a consumer group
+type GroupDescription struct {
+	GroupID string
+	State   string
+	Members []GroupMember
+}
+
+// PartitionLag is the lag of a consumer group on one partition
+type PartitionLag struct {
+	Topic           string
+	Partition       int
+	CommittedOffset int64 // -1 when the group has not committed on the partition
+	EndOffset       int64
+	Lag             int64
+}
+
+// KafkaAdmin manages topics and inspects consumer groups
+type KafkaAdmin interface {
+	// CreateTopics creates the topics; topics that already exist are left unchanged
+	CreateTopics(ctx context.Context, topics ...TopicConfig) error
+	DeleteTopics(ctx context.Context, names ...string) error
+	ListTopics(ctx context.Context) ([]TopicInfo, error)
+	DescribeGroup(ctx context.Context, groupID string) (GroupDescription, error)
+	// ConsumerLag returns the lag of groupID on every partition of topic
+	ConsumerLag(ctx context.Context, groupID, topic string) ([]PartitionLag, error)
+	Close() error
+}
+
+// kafkaAdmin implements KafkaAdmin
+type kafkaAdmin struct {
+	client    *kafka.Client
+	transport *kafka.Transport
+}
+
+// NewKafkaAdmin creates an admin client for the brokers in cfg, using the same
+// client ID, SASL and TLS settings as the producer
+func NewKafkaAdmin(cfg *KafkaConfig) (KafkaAdmin, error) {
+	transport, err := newTransport(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaAdmin{
+		client: &kafka.Client{
+			Addr:      kafka.TCP(cfg.Brokers...),
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		transport: transport,
+	}, nil
+}
+
+// CreateTopics creates topics with their partitions and configs
+func (a *kafkaAdmin) CreateTopics(ctx context.Context, topics ...TopicConfig) error {
+	req := &kafka.CreateTopicsRequest{Topics: make([]kafka.TopicConfig, len(topics))}
+	for i, topic := range topics {
+		partitions, replication := topic.Partitions, topic.ReplicationFactor
+		if partitions <= 0 {
+			partitions = 1
+		}
+		if replication <= 0 {
+			replication = 1
+		}
+		entries := make([]kafka.ConfigEntry, 0, len(topic.Configs))
+		for name, value := range topic.Configs {
+			entries = append(entries, kafka.ConfigEntry{ConfigName: name, ConfigValue: value})
+		}
+		req.Topics[i] = kafka.TopicConfig{
+			Topic:             topic.Name,
+			NumPartitions:     partitions,
+			ReplicationFactor: replication,
+			ConfigEntries:     entries,
+		}
+	}
+	resp, err := a.client.CreateTopics(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to create topics: %w", err)
+	}
+	for name, err := range resp.Errors {
+		if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+			return fmt.Errorf("failed to create topic %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteTopics deletes topics
+func (a *kafkaAdmin) DeleteTopics(ctx context.Context, names ...string) error {
+	resp, err := a.client.DeleteTopics(ctx, &kafka.DeleteTopicsRequest{Topics: names})
+	if err != nil {
+		return fmt.Errorf("failed to delete topics: %w", err)
+	}
+	for name, err := range resp.Errors {
+		if err != nil {
+			return fmt.Errorf("failed to delete topic %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ListTopics returns every topic in the cluster, sorted by name
+func (a *kafkaAdmin) ListTopics(ctx context.Context) ([]TopicInfo, error) {
+	resp, err := a.client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	topics := make([]TopicInfo, 0, len(resp.Topics))
+	for _, topic := range resp.Topics {
+		if topic.Error != nil {
+			return nil, fmt.Errorf("failed to describe topic %s: %w", topic.Name, topic.Error)
+		}
+		info := TopicInfo{Name: topic.Name, Partitions: len(topic.Partitions), Internal: topic.Internal}
+		if len(topic.Partitions) > 0 {
+			info.ReplicationFactor = len(topic.Partitions[0].Replicas)
+		}
+		topics = append(topics, info)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics, nil
+}
+
+// DescribeGroup returns the state and members of a consumer group
+func (a *kafkaAdmin) DescribeGroup(ctx context.Context, groupID string) (GroupDescription, error) {
+	resp, err := a.client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupID}})
+	if err != nil {
+		return GroupDescription{}, fmt.Errorf("failed to describe consumer group %s: %w", groupID, err)
+	}
+	if len(resp.Groups) == 0 {
+		return GroupDescription{}, fmt.Errorf("consumer group %s not found", groupID)
+	}
+	group := resp.Groups[0]
+	if group.Error != nil {
+		return GroupDescription{}, fmt.Errorf("failed to describe consumer group %s: %w", groupID, group.Error)
+	}
+	desc := GroupDescription{GroupID: group.GroupID, State: group.GroupState, Members: make([]GroupMember, len(group.Members))}
+	for i, member := range group.Members {
+		assignments := make(map[string][]int, len(member.MemberAssignments.Topics))
+		for _, topic := range member.MemberAssignments.Topics {
+			assignments[topic.Topic] = topic.Partitions
+		}
+		desc.Members[i] = GroupMember{
+			MemberID:    member.MemberID,
+			ClientID:    member.ClientID,
+			ClientHost:  member.ClientHost,
+			Assignments: assignments,
+		}
+	}
+	return desc, nil
+}
+
+// ConsumerLag compares the group's committed offsets with the end offsets of
+// each partition. Without a commit, the lag counts every retained message.
+func (a *kafkaAdmin) ConsumerLag(ctx context.Context, groupID, topic string) ([]PartitionLag, error) {
+	meta, err := a.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", topic, err)
+	}
+	if len(meta.Topics) == 0 || meta.Topics[0].Error != nil {
+		if len(meta.Topics) > 0 {
+			err = meta.Topics[0].Error
+		}
+		return nil, fmt.Errorf("failed to describe topic %s: %w", topic, err)
+	}
+	partitions := make([]int, len(meta.Topics[0].Partitions))
+	offsetRequests := make([]kafka.OffsetRequest, 0, 2*len(partitions))
+	for i, partition := range meta.Topics[0].Partitions {
+		partitions[i] = partition.ID
+		offsetRequests = append(offsetRequests, kafka.FirstOffsetOf(partition.ID), kafka.LastOffsetOf(partition.ID))
+	}
+	sort.Ints(partitions)
+
+	committed, err := a.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: groupID, Topics: map[string][]int{topic: partitions}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch offsets of consumer group %s: %w", groupID, err)
+	}
+	if committed.Error != nil {
+		return nil, fmt.Errorf("failed to fetch offsets of consumer group %s: %w", groupID, committed.Error)
+	}
+	ends, err := a.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{topic: offsetRequests}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of topic %s: %w", topic, err)
+	}
+
+	committedBy := make(map[int]int64, len(partitions))
+	for _, p := range committed.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to fetch offset of %s[%d]: %w", topic, p.Partition, p.Error)
+		}
+		committedBy[p.Partition] = p.CommittedOffset
+	}
+	offsetsBy := make(map[int]kafka.PartitionOffsets, len(partitions))
+	for _, p := range ends.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of %s[%d]: %w", topic, p.Partition, p.Error)
+		}
+		offsetsBy[p.Partition] = p
+	}
+
+	lags := make([]PartitionLag, len(partitions))
+	for i, partition := range partitions {
+		offsets := offsetsBy[partition]
+		lag := PartitionLag{Topic: topic, Partition: partition, CommittedOffset: -1, EndOffset: offsets.LastOffset}
+		if offset, ok := committedBy[partition]; ok && offset >= 0 {
+			lag.CommittedOffset = offset
+			lag.Lag = offsets.LastOffset - offset
+		} else {
+			lag.Lag = offsets.LastOffset - offsets.FirstOffset
+		}
+		lag.Lag = max(lag.Lag, 0)
+		lags[i] = lag
+	}
+	return lags, nil
+}
+
+// Close releases idle broker connections
+func (a *kafkaAdmin) Close() error {
+	a.transport.CloseIdleConnections()
+	return nil
+}
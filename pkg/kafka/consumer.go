@@ -0,0 +1,190 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// messageReader is the subset of *kafka.Reader used by the consumer
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// consumer dispatches fetched messages to workers and commits completed offsets
+type consumer struct {
+	reader  messageReader
+	handler MessageHandler
+	workers int
+	tracker *offsetTracker
+	metrics *consumerMetrics
+
+	errOnce sync.Once
+	err     error
+	cancel  context.CancelFunc
+}
+
+// newConsumer creates a consumer with the given number of workers
+func newConsumer(reader messageReader, handler MessageHandler, workers int) *consumer {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &consumer{
+		reader:  reader,
+		handler: handler,
+		workers: workers,
+		tracker: newOffsetTracker(),
+	}
+}
+
+// run fetches until ctx is cancelled or a worker fails, then waits for in-flight
+// messages. Handlers get handlerCtx, so cancelling only ctx lets them finish.
+func (c *consumer) run(ctx, handlerCtx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	defer c.cancel()
+
+	queues := make([]chan kafka.Message, c.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, 64)
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			c.work(ctx, handlerCtx, queue)
+		}(queues[i])
+	}
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.fail(fmt.Errorf("error reading message from Kafka: %w", err))
+			}
+			break
+		}
+		c.tracker.add(msg)
+		c.metrics.fetched(msg)
+		select {
+		case queues[c.workerFor(msg)] <- msg:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+	return c.err
+}
+
+// work handles messages sequentially, committing the partition watermark after each success
+func (c *consumer) work(ctx, handlerCtx context.Context, queue <-chan kafka.Message) {
+	for msg := range queue {
+		if ctx.Err() != nil {
+			continue // Drain without handling; uncommitted messages are redelivered
+		}
+		start := time.Now()
+		err := c.handler(handlerCtx, msg)
+		c.metrics.handle(time.Since(start), err)
+		if err != nil {
+			c.fail(fmt.Errorf("handler failed for %s[%d]@%d: %w", msg.Topic, msg.Partition, msg.Offset, err))
+			continue
+		}
+		if commit, ok := c.tracker.done(msg); ok {
+			// Commit even if the consumer is stopping so finished work is not redelivered
+			if err := c.reader.CommitMessages(context.WithoutCancel(handlerCtx), commit); err != nil {
+				c.metrics.commitFailed()
+				c.fail(fmt.Errorf("failed to commit offset: %w", err))
+			}
+		}
+	}
+}
+
+// fail records the first error and stops the consumer
+func (c *consumer) fail(err error) {
+	c.errOnce.Do(func() {
+		c.err = err
+		c.cancel()
+	})
+}
+
+// workerFor hashes the message key (or partition for keyless messages) onto a worker
+func (c *consumer) workerFor(msg kafka.Message) int {
+	if c.workers == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	if len(msg.Key) > 0 {
+		h.Write(msg.Key)
+	} else {
+		h.Write([]byte(strconv.Itoa(msg.Partition)))
+	}
+	return int(h.Sum32() % uint32(c.workers))
+}
+
+// offsetTracker computes, per partition, the highest offset below which every
+// fetched message has been handled
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[string]*partitionOffsets
+}
+
+// partitionOffsets holds fetched offsets in order and which of them are done
+type partitionOffsets struct {
+	pending []int64
+	done    map[int64]bool
+}
+
+// newOffsetTracker creates an empty tracker
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[string]*partitionOffsets)}
+}
+
+// add records a fetched message. A fetch at or below the last pending offset
+// means the partition was rewound (e.g. after a rebalance) and resets it.
+func (t *offsetTracker) add(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := partitionKey(msg.Topic, msg.Partition)
+	p, ok := t.partitions[key]
+	if !ok || (len(p.pending) > 0 && msg.Offset <= p.pending[len(p.pending)-1]) {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[key] = p
+	}
+	p.pending = append(p.pending, msg.Offset)
+}
+
+// done marks a message handled and returns the message to commit when the watermark advanced
+func (t *offsetTracker) done(msg kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[partitionKey(msg.Topic, msg.Partition)]
+	if !ok {
+		return kafka.Message{}, false
+	}
+	p.done[msg.Offset] = true
+	committed := int64(-1)
+	for len(p.pending) > 0 && p.done[p.pending[0]] {
+		committed = p.pending[0]
+		delete(p.done, committed)
+		p.pending = p.pending[1:]
+	}
+	if committed < 0 {
+		return kafka.Message{}, false
+	}
+	return kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: committed}, true
+}
+
+// partitionKey identifies a topic partition
+func partitionKey(topic string, partition int) string {
+	return topic + "/" + strconv.Itoa(partition)
+}
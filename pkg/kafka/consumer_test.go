@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	type step struct {
+		add    bool // Fetch the offset instead of finishing it
+		offset int64
+		commit int64 // Expected committed offset after done, -1 for none
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "in order",
+			steps: []step{
+				{add: true, offset: 0}, {add: true, offset: 1},
+				{offset: 0, commit: 0}, {offset: 1, commit: 1},
+			},
+		},
+		{
+			name: "out of order waits for the lowest offset",
+			steps: []step{
+				{add: true, offset: 0}, {add: true, offset: 1}, {add: true, offset: 2},
+				{offset: 2, commit: -1}, {offset: 1, commit: -1}, {offset: 0, commit: 2},
+			},
+		},
+		{
+			name: "gaps in offsets",
+			steps: []step{
+				{add: true, offset: 5}, {add: true, offset: 9},
+				{offset: 9, commit: -1}, {offset: 5, commit: 9},
+			},
+		},
+		{
+			name: "rewind resets the partition",
+			steps: []step{
+				{add: true, offset: 3}, {add: true, offset: 4},
+				{add: true, offset: 3}, {offset: 3, commit: 3}, {offset: 4, commit: -1},
+			},
+		},
+		{
+			name: "unknown partition",
+			steps: []step{
+				{offset: 7, commit: -1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOffsetTracker()
+			for i, s := range tt.steps {
+				msg := kafka.Message{Topic: "orders", Partition: 1, Offset: s.offset}
+				if s.add {
+					tracker.add(msg)
+					continue
+				}
+				commit, ok := tracker.done(msg)
+				switch {
+				case s.commit < 0 && ok:
+					t.Fatalf("step %d: committed %d, want no commit", i, commit.Offset)
+				case s.commit >= 0 && !ok:
+					t.Fatalf("step %d: no commit, want %d", i, s.commit)
+				case ok && (commit.Offset != s.commit || commit.Topic != "orders" || commit.Partition != 1):
+					t.Fatalf("step %d: committed %s[%d]@%d, want orders[1]@%d", i, commit.Topic, commit.Partition, commit.Offset, s.commit)
+				}
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerPartitionsAreIndependent(t *testing.T) {
+	tracker := newOffsetTracker()
+	a := kafka.Message{Topic: "orders", Partition: 0, Offset: 0}
+	b := kafka.Message{Topic: "orders", Partition: 1, Offset: 0}
+	tracker.add(a)
+	tracker.add(b)
+	if commit, ok := tracker.done(b); !ok || commit.Partition != 1 {
+		t.Fatalf("partition 1 not committed: %v %v", commit, ok)
+	}
+	if commit, ok := tracker.done(a); !ok || commit.Partition != 0 {
+		t.Fatalf("partition 0 not committed: %v %v", commit, ok)
+	}
+}
@@ -0,0 +1,395 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rk-the-dev/golib-core/pkg/server/shutdown"
+	"github.com/segmentio/kafka-go"
+)
+
+// Consumer is a background consumer started with StartConsumer
+type Consumer interface {
+	Topic() string
+	GroupID() string
+	// Stop stops fetching and waits for in-flight messages to be handled and
+	// committed. When ctx is done first, handler contexts are cancelled.
+	Stop(ctx context.Context) error
+	// Done is closed once the consumer has stopped
+	Done() <-chan struct{}
+	// Err returns the error that stopped the consumer, or nil once Done is closed
+	Err() error
+}
+
+// ConsumerOptions configures a consumer started with StartConsumer
+type ConsumerOptions struct {
+	// OnAssigned is called with the partitions assigned to this member after
+	// each consumer group rebalance, before any of them is read
+	OnAssigned func(ctx context.Context, partitions []int)
+	// OnRevoked is called with the previous assignment once its in-flight
+	// messages are handled and committed, before the group rebalances
+	OnRevoked func(ctx context.Context, partitions []int)
+}
+
+// managedConsumer implements Consumer
+type managedConsumer struct {
+	id      int64
+	topic   string
+	groupID string
+	stop    context.CancelFunc // Stops fetching
+	abort   context.CancelFunc // Cancels handler contexts
+	done    chan struct{}
+	err     error
+}
+
+// Topic returns the consumed topic
+func (c *managedConsumer) Topic() string {
+	return c.topic
+}
+
+// GroupID returns the consumer group, empty for a single-partition reader
+func (c *managedConsumer) GroupID() string {
+	return c.groupID
+}
+
+// Stop stops the consumer gracefully
+func (c *managedConsumer) Stop(ctx context.Context) error {
+	c.stop()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.abort()
+		<-c.done
+	}
+	return c.err
+}
+
+// Done is closed once the consumer has stopped
+func (c *managedConsumer) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that stopped the consumer
+func (c *managedConsumer) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// consumerRegistry tracks the running consumers of a client
+type consumerRegistry struct {
+	mu        sync.Mutex
+	consumers map[int64]*managedConsumer
+	nextID    int64
+	closed    bool
+}
+
+// start runs fn in the background as a managed consumer. fn must stop fetching
+// when fetchCtx is cancelled and pass handlerCtx to handlers.
+func (r *consumerRegistry) start(ctx context.Context, topic, groupID string, fn func(fetchCtx, handlerCtx context.Context) error) (Consumer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, fmt.Errorf("kafka client is closed")
+	}
+	if r.consumers == nil {
+		r.consumers = make(map[int64]*managedConsumer)
+	}
+
+	handlerCtx, abort := context.WithCancel(ctx)
+	fetchCtx, stop := context.WithCancel(handlerCtx)
+	r.nextID++
+	c := &managedConsumer{
+		id:      r.nextID,
+		topic:   topic,
+		groupID: groupID,
+		stop:    stop,
+		abort:   abort,
+		done:    make(chan struct{}),
+	}
+	r.consumers[c.id] = c
+
+	go func() {
+		defer close(c.done)
+		defer abort()
+		c.err = fn(fetchCtx, handlerCtx)
+		r.mu.Lock()
+		delete(r.consumers, c.id)
+		r.mu.Unlock()
+	}()
+	return c, nil
+}
+
+// list returns the running consumers
+func (r *consumerRegistry) list() []Consumer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	consumers := make([]Consumer, 0, len(r.consumers))
+	for _, c := range r.consumers {
+		consumers = append(consumers, c)
+	}
+	return consumers
+}
+
+// stopAll closes the registry and stops all consumers concurrently, returning
+// the first consumer error. It reports false when the registry was already closed.
+func (r *consumerRegistry) stopAll(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return false, nil
+	}
+	r.closed = true
+	consumers := make([]*managedConsumer, 0, len(r.consumers))
+	for _, c := range r.consumers {
+		consumers = append(consumers, c)
+	}
+	r.mu.Unlock()
+
+	errs := make(chan error, len(consumers))
+	var wg sync.WaitGroup
+	for _, c := range consumers {
+		wg.Add(1)
+		go func(c *managedConsumer) {
+			defer wg.Done()
+			if err := c.Stop(ctx); err != nil {
+				errs <- err
+			}
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+	return true, <-errs
+}
+
+// StartConsumer starts a consumer for topic in the background. With a groupID
+// it joins the consumer group and reads the assigned partitions; without one it
+// reads the configured Partition. Cancelling ctx stops it without waiting for
+// in-flight messages; use Stop or Shutdown to drain.
+func (k *kafkaClient) StartConsumer(ctx context.Context, topic, groupID string, handler MessageHandler, opts ConsumerOptions) (Consumer, error) {
+	c, err := k.consumers.start(ctx, topic, groupID, func(fetchCtx, handlerCtx context.Context) error {
+		defer fmt.Println("🔻 Stopped consuming from topic:", topic)
+		return k.runConsumer(fetchCtx, handlerCtx, topic, groupID, handler, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("🔄 Consuming messages from topic:", topic)
+	return c, nil
+}
+
+// Consumers returns the running consumers
+func (k *kafkaClient) Consumers() []Consumer {
+	return k.consumers.list()
+}
+
+// Shutdown stops all consumers concurrently, then closes the producer. It
+// returns the first consumer error.
+func (k *kafkaClient) Shutdown(ctx context.Context) error {
+	stopped, err := k.consumers.stopAll(ctx)
+	if !stopped {
+		return nil
+	}
+	if k.writer != nil {
+		k.writer.Close()
+	}
+	fmt.Println("🔻 Kafka client closed")
+	return err
+}
+
+// RegisterShutdownHook drains the client's consumers and closes it on shutdown
+func RegisterShutdownHook(helper shutdown.ShutdownHelper, client KafkaClient) {
+	helper.RegisterShutdownHook("kafka", func(ctx context.Context) {
+		if err := client.Shutdown(ctx); err != nil {
+			fmt.Println("❌ Kafka shutdown failed:", err)
+		}
+	})
+}
+
+// runConsumer consumes until ctx is cancelled or the handler fails
+func (k *kafkaClient) runConsumer(ctx, handlerCtx context.Context, topic, groupID string, handler MessageHandler, opts ConsumerOptions) error {
+	metrics := newConsumerMetrics(k.cfg.Metrics, topic, groupID)
+	done := make(chan struct{})
+	defer close(done)
+	go metrics.reportRate(done)
+
+	if groupID == "" {
+		reader := kafka.NewReader(k.readerConfig(topic, ""))
+		defer reader.Close()
+		c := newConsumer(partitionReader{reader}, handler, k.cfg.ConsumerWorkers)
+		c.metrics = metrics
+		return c.run(ctx, handlerCtx)
+	}
+
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:                    groupID,
+		Brokers:               k.cfg.Brokers,
+		Dialer:                k.dialer,
+		Topics:                []string{topic},
+		StartOffset:           k.startOffset(),
+		WatchPartitionChanges: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer group %s: %w", groupID, err)
+	}
+	defer group.Close()
+
+	for ctx.Err() == nil {
+		gen, err := group.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return fmt.Errorf("failed to join consumer group %s: %w", groupID, err)
+		}
+		if err := k.runGeneration(ctx, handlerCtx, gen, topic, handler, metrics, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runGeneration reads the partitions assigned in a group generation until the
+// group rebalances or ctx is cancelled. In-flight messages are handled and
+// committed before the generation is released.
+func (k *kafkaClient) runGeneration(ctx, handlerCtx context.Context, gen *kafka.Generation, topic string, handler MessageHandler, metrics *consumerMetrics, opts ConsumerOptions) error {
+	assignments := gen.Assignments[topic]
+	partitions := make([]int, len(assignments))
+	for i, assignment := range assignments {
+		partitions[i] = assignment.ID
+	}
+
+	result := make(chan error, 1)
+	gen.Start(func(genCtx context.Context) {
+		fetchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stopWatch := context.AfterFunc(genCtx, cancel)
+		defer stopWatch()
+
+		if opts.OnAssigned != nil {
+			opts.OnAssigned(handlerCtx, partitions)
+		}
+
+		reader := newGenerationReader(gen, genCtx)
+		var wg sync.WaitGroup
+		for _, assignment := range assignments {
+			cfg := k.readerConfig(topic, "")
+			cfg.Partition = assignment.ID
+			wg.Add(1)
+			go func(offset int64) {
+				defer wg.Done()
+				reader.fetch(fetchCtx, cfg, offset)
+			}(assignment.Offset)
+		}
+
+		c := newConsumer(reader, handler, k.cfg.ConsumerWorkers)
+		c.metrics = metrics
+		err := c.run(fetchCtx, handlerCtx)
+		cancel()
+		wg.Wait()
+
+		if opts.OnRevoked != nil {
+			opts.OnRevoked(handlerCtx, partitions)
+		}
+		result <- err
+	})
+	return <-result
+}
+
+// generationReader merges the partition readers of a group generation and
+// commits offsets through the generation
+type generationReader struct {
+	gen      *kafka.Generation
+	genCtx   context.Context
+	messages chan kafka.Message
+	errs     chan error
+}
+
+// newGenerationReader creates a reader for a generation
+func newGenerationReader(gen *kafka.Generation, genCtx context.Context) *generationReader {
+	return &generationReader{
+		gen:      gen,
+		genCtx:   genCtx,
+		messages: make(chan kafka.Message),
+		errs:     make(chan error, 1),
+	}
+}
+
+// fetch reads one partition from offset until ctx is cancelled
+func (r *generationReader) fetch(ctx context.Context, cfg kafka.ReaderConfig, offset int64) {
+	reader := kafka.NewReader(cfg)
+	defer reader.Close()
+	if err := reader.SetOffset(offset); err != nil {
+		r.fail(ctx, fmt.Errorf("failed to seek partition %d: %w", cfg.Partition, err))
+		return
+	}
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.fail(ctx, err)
+			}
+			return
+		}
+		select {
+		case r.messages <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fail reports a partition read error to the consumer
+func (r *generationReader) fail(ctx context.Context, err error) {
+	select {
+	case r.errs <- err:
+	case <-ctx.Done():
+	}
+}
+
+// FetchMessage returns the next message from any assigned partition
+func (r *generationReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case err := <-r.errs:
+		return kafka.Message{}, err
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+// CommitMessages commits the offsets following msgs. A failure after the
+// generation ended is not an error: the new owner redelivers the messages.
+func (r *generationReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	offsets := make(map[string]map[int]int64)
+	for _, msg := range msgs {
+		if offsets[msg.Topic] == nil {
+			offsets[msg.Topic] = make(map[int]int64)
+		}
+		offsets[msg.Topic][msg.Partition] = msg.Offset + 1
+	}
+	if err := r.gen.CommitOffsets(offsets); err != nil {
+		if r.genCtx.Err() != nil {
+			fmt.Println("⚠️ Offset commit after rebalance failed, messages will be redelivered:", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// partitionReader reads a single partition without a consumer group, so there
+// are no offsets to commit
+type partitionReader struct {
+	*kafka.Reader
+}
+
+// CommitMessages is a no-op without a consumer group
+func (partitionReader) CommitMessages(context.Context, ...kafka.Message) error {
+	return nil
+}
@@ -0,0 +1,483 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	_ KafkaClient = (*FakeKafkaClient)(nil)
+	_ KafkaAdmin  = (*FakeKafkaClient)(nil)
+)
+
+// FakeKafkaClient is an in-memory KafkaClient and KafkaAdmin for unit tests.
+// Topics produced to are created with one partition; create them with
+// CreateTopics to get more. Keyed messages are partitioned by key hash like
+// the Hash balancer. A group consumer claims each partition it reads until it
+// stops, so consumers of the same group split the partitions between them, and
+// committed offsets survive consumer restarts. Messages are handled by a single
+// worker in fetch order.
+type FakeKafkaClient struct {
+	// Metrics receives the same metrics as the real client; set it before use
+	Metrics metricshelper.MetricsHelper
+
+	mu         sync.Mutex
+	topics     map[string]*fakeTopic
+	committed  map[string]map[string]int64 // group -> topic/partition -> next offset
+	groups     map[string]*fakeGroup       // group/topic -> partition owners and positions
+	members    int64
+	produceErr error
+	changed    chan struct{} // Closed and replaced whenever messages or offsets change
+	balancer   *kafka.Hash
+	consumers  consumerRegistry
+}
+
+// fakeTopic holds the messages of a topic by partition and in produce order
+type fakeTopic struct {
+	partitions [][]kafka.Message
+	all        []kafka.Message
+}
+
+// fakeGroup tracks the running consumers of a group on a topic, which of them
+// owns each partition and the fetch position of each partition
+type fakeGroup struct {
+	members  []*fakeReader
+	owner    map[int]*fakeReader
+	position map[int]int64
+}
+
+// NewFakeKafkaClient creates an empty in-memory Kafka
+func NewFakeKafkaClient() *FakeKafkaClient {
+	return &FakeKafkaClient{
+		topics:    make(map[string]*fakeTopic),
+		committed: make(map[string]map[string]int64),
+		groups:    make(map[string]*fakeGroup),
+		changed:   make(chan struct{}),
+		balancer:  &kafka.Hash{},
+	}
+}
+
+// Produce stores a message
+func (f *FakeKafkaClient) Produce(ctx context.Context, topic string, key, message []byte) error {
+	if err := f.ProduceMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: message}); err != nil {
+		return fmt.Errorf("failed to send message to Kafka: %w", err)
+	}
+	return nil
+}
+
+// ProduceMessages stores messages, assigning their partition, offset and time.
+// Either every message is stored or none is.
+func (f *FakeKafkaClient) ProduceMessages(ctx context.Context, messages ...kafka.Message) error {
+	start := time.Now()
+	err := f.produce(ctx, messages)
+	observeProduce(f.Metrics, "", messages, time.Since(start), err)
+	return err
+}
+
+// produce stores messages
+func (f *FakeKafkaClient) produce(ctx context.Context, messages []kafka.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.produceErr != nil {
+		return f.produceErr
+	}
+	for _, msg := range messages {
+		if msg.Topic == "" {
+			return fmt.Errorf("message topic cannot be empty")
+		}
+	}
+	for _, msg := range messages {
+		t := f.topic(msg.Topic, 1)
+		partitions := make([]int, len(t.partitions))
+		for i := range partitions {
+			partitions[i] = i
+		}
+		msg.Partition = f.balancer.Balance(msg, partitions...)
+		msg.Offset = int64(len(t.partitions[msg.Partition]))
+		msg.Headers = copyHeaders(msg.Headers)
+		if msg.Time.IsZero() {
+			msg.Time = time.Now()
+		}
+		t.partitions[msg.Partition] = append(t.partitions[msg.Partition], msg)
+		t.all = append(t.all, msg)
+	}
+	f.notify()
+	return nil
+}
+
+// Consume handles messages until ctx is cancelled or the handler fails
+func (f *FakeKafkaClient) Consume(ctx context.Context, topic, groupID string, handler MessageHandler) error {
+	c, err := f.StartConsumer(ctx, topic, groupID, handler, ConsumerOptions{})
+	if err != nil {
+		return err
+	}
+	<-c.Done()
+	return c.Err()
+}
+
+// StartConsumer starts a background consumer. Without a groupID it reads every
+// partition from the beginning and commits nothing. A group consumer claims
+// unowned partitions as it reads them, reporting each through OnAssigned, and
+// releases them through OnRevoked when it stops.
+func (f *FakeKafkaClient) StartConsumer(ctx context.Context, topic, groupID string, handler MessageHandler, opts ConsumerOptions) (Consumer, error) {
+	return f.consumers.start(ctx, topic, groupID, func(fetchCtx, handlerCtx context.Context) error {
+		reader := f.join(topic, groupID)
+		if opts.OnAssigned != nil {
+			reader.onAssigned = func(partition int) { opts.OnAssigned(handlerCtx, []int{partition}) }
+		}
+		metrics := newConsumerMetrics(f.Metrics, topic, groupID)
+		done := make(chan struct{})
+		defer close(done)
+		go metrics.reportRate(done)
+
+		c := newConsumer(reader, handler, 1)
+		c.metrics = metrics
+		err := c.run(fetchCtx, handlerCtx)
+		if revoked := f.leave(reader); len(revoked) > 0 && opts.OnRevoked != nil {
+			opts.OnRevoked(handlerCtx, revoked)
+		}
+		return err
+	})
+}
+
+// Consumers returns the running consumers
+func (f *FakeKafkaClient) Consumers() []Consumer {
+	return f.consumers.list()
+}
+
+// Shutdown stops every consumer, letting in-flight messages finish until ctx is done
+func (f *FakeKafkaClient) Shutdown(ctx context.Context) error {
+	_, err := f.consumers.stopAll(ctx)
+	return err
+}
+
+// Close stops every consumer, waiting up to 10s for in-flight messages
+func (f *FakeKafkaClient) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return f.Shutdown(ctx)
+}
+
+// CreateTopics creates topics with their partition counts; existing topics are left unchanged
+func (f *FakeKafkaClient) CreateTopics(_ context.Context, topics ...TopicConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, topic := range topics {
+		if topic.Name == "" {
+			return fmt.Errorf("topic name cannot be empty")
+		}
+		f.topic(topic.Name, max(topic.Partitions, 1))
+	}
+	return nil
+}
+
+// DeleteTopics removes topics and their messages
+func (f *FakeKafkaClient) DeleteTopics(_ context.Context, names ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, name := range names {
+		if _, ok := f.topics[name]; !ok {
+			return fmt.Errorf("failed to delete topic %s: %w", name, kafka.UnknownTopicOrPartition)
+		}
+	}
+	for _, name := range names {
+		delete(f.topics, name)
+	}
+	return nil
+}
+
+// ListTopics returns the topics sorted by name
+func (f *FakeKafkaClient) ListTopics(_ context.Context) ([]TopicInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	topics := make([]TopicInfo, 0, len(f.topics))
+	for name, t := range f.topics {
+		topics = append(topics, TopicInfo{Name: name, Partitions: len(t.partitions), ReplicationFactor: 1})
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics, nil
+}
+
+// DescribeGroup returns one member per running consumer of the group with the
+// partitions it has claimed. The state is Stable with members, Empty with only
+// committed offsets and Dead otherwise.
+func (f *FakeKafkaClient) DescribeGroup(_ context.Context, groupID string) (GroupDescription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	desc := GroupDescription{GroupID: groupID, State: "Dead"}
+	if _, ok := f.committed[groupID]; ok {
+		desc.State = "Empty"
+	}
+	for _, g := range f.groups {
+		for _, member := range g.members {
+			if member.groupID != groupID {
+				continue
+			}
+			desc.State = "Stable"
+			desc.Members = append(desc.Members, GroupMember{
+				MemberID:    member.memberID,
+				ClientID:    "fake",
+				ClientHost:  "localhost",
+				Assignments: map[string][]int{member.topic: g.owned(member)},
+			})
+		}
+	}
+	sort.Slice(desc.Members, func(i, j int) bool { return desc.Members[i].MemberID < desc.Members[j].MemberID })
+	return desc, nil
+}
+
+// ConsumerLag returns the lag of groupID on every partition of topic
+func (f *FakeKafkaClient) ConsumerLag(_ context.Context, groupID, topic string) ([]PartitionLag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.topics[topic]
+	if !ok {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", topic, kafka.UnknownTopicOrPartition)
+	}
+	lags := make([]PartitionLag, len(t.partitions))
+	for partition, messages := range t.partitions {
+		end := int64(len(messages))
+		lag := PartitionLag{Topic: topic, Partition: partition, CommittedOffset: -1, EndOffset: end, Lag: end}
+		if offset, ok := f.committed[groupID][partitionKey(topic, partition)]; ok {
+			lag.CommittedOffset = offset
+			lag.Lag = end - offset
+		}
+		lags[partition] = lag
+	}
+	return lags, nil
+}
+
+// Messages returns the messages produced to topic, in produce order
+func (f *FakeKafkaClient) Messages(topic string) []kafka.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t, ok := f.topics[topic]; ok {
+		return append([]kafka.Message(nil), t.all...)
+	}
+	return nil
+}
+
+// PartitionMessages returns the messages of one partition, in offset order
+func (f *FakeKafkaClient) PartitionMessages(topic string, partition int) []kafka.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t, ok := f.topics[topic]; ok && partition >= 0 && partition < len(t.partitions) {
+		return append([]kafka.Message(nil), t.partitions[partition]...)
+	}
+	return nil
+}
+
+// CommittedOffset returns the next offset the group will read from a
+// partition, or -1 when the group has not committed on it
+func (f *FakeKafkaClient) CommittedOffset(groupID, topic string, partition int) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if offset, ok := f.committed[groupID][partitionKey(topic, partition)]; ok {
+		return offset
+	}
+	return -1
+}
+
+// WaitForCommit blocks until the group has committed up to offset on the
+// partition, i.e. handled every message before it, or ctx is done
+func (f *FakeKafkaClient) WaitForCommit(ctx context.Context, groupID, topic string, partition int, offset int64) error {
+	for {
+		f.mu.Lock()
+		committed, ok := f.committed[groupID][partitionKey(topic, partition)]
+		changed := f.changed
+		f.mu.Unlock()
+		if ok && committed >= offset {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SetProduceError makes every following produce fail with err until it is reset with nil
+func (f *FakeKafkaClient) SetProduceError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.produceErr = err
+}
+
+// topic returns the named topic, creating it with the given partitions. The caller holds f.mu.
+func (f *FakeKafkaClient) topic(name string, partitions int) *fakeTopic {
+	t, ok := f.topics[name]
+	if !ok {
+		t = &fakeTopic{partitions: make([][]kafka.Message, partitions)}
+		f.topics[name] = t
+	}
+	return t
+}
+
+// notify wakes up fetchers and waiters. The caller holds f.mu.
+func (f *FakeKafkaClient) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// join adds a consumer to its group and returns its reader
+func (f *FakeKafkaClient) join(topic, groupID string) *fakeReader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members++
+	reader := &fakeReader{fake: f, topic: topic, groupID: groupID, memberID: "fake-" + strconv.FormatInt(f.members, 10)}
+	if groupID == "" {
+		reader.group = newFakeGroup()
+		reader.group.members = []*fakeReader{reader}
+		return reader
+	}
+	key := groupID + "/" + topic
+	g, ok := f.groups[key]
+	if !ok {
+		g = newFakeGroup()
+		f.groups[key] = g
+	}
+	g.members = append(g.members, reader)
+	reader.group = g
+	return reader
+}
+
+// leave removes a stopped consumer from its group and returns the partitions it
+// released. The next owner reads them from the committed offsets, so
+// uncommitted messages are redelivered.
+func (f *FakeKafkaClient) leave(reader *fakeReader) []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := reader.group
+	released := g.owned(reader)
+	for _, partition := range released {
+		delete(g.owner, partition)
+		delete(g.position, partition)
+	}
+	for i, member := range g.members {
+		if member == reader {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	if reader.groupID != "" && len(g.members) == 0 {
+		delete(f.groups, reader.groupID+"/"+reader.topic)
+	}
+	f.notify()
+	return released
+}
+
+// newFakeGroup creates a group without members
+func newFakeGroup() *fakeGroup {
+	return &fakeGroup{owner: make(map[int]*fakeReader), position: make(map[int]int64)}
+}
+
+// owned returns the partitions owned by reader, sorted
+func (g *fakeGroup) owned(reader *fakeReader) []int {
+	partitions := []int{}
+	for partition, owner := range g.owner {
+		if owner == reader {
+			partitions = append(partitions, partition)
+		}
+	}
+	sort.Ints(partitions)
+	return partitions
+}
+
+// fakeReader fetches from the in-memory topics and commits group offsets
+type fakeReader struct {
+	fake       *FakeKafkaClient
+	topic      string
+	groupID    string
+	memberID   string
+	group      *fakeGroup
+	next       int // Partition to try first, rotated for fairness
+	onAssigned func(partition int)
+}
+
+// FetchMessage returns the next unread message of a partition this reader
+// owns or can claim, rotating over partitions
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		r.fake.mu.Lock()
+		msg, claimed, ok := r.pop()
+		changed := r.fake.changed
+		r.fake.mu.Unlock()
+		if claimed && r.onAssigned != nil {
+			r.onAssigned(msg.Partition)
+		}
+		if ok {
+			return msg, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		}
+	}
+}
+
+// pop takes the next message and reports whether its partition was just
+// claimed. The caller holds fake.mu.
+func (r *fakeReader) pop() (kafka.Message, bool, bool) {
+	t, ok := r.fake.topics[r.topic]
+	if !ok {
+		return kafka.Message{}, false, false
+	}
+	n := len(t.partitions)
+	for i := range n {
+		partition := (r.next + i) % n
+		owner, owned := r.group.owner[partition]
+		if owned && owner != r {
+			continue
+		}
+		offset, ok := r.group.position[partition]
+		if !ok {
+			offset = max(r.fake.committed[r.groupID][partitionKey(r.topic, partition)], 0)
+		}
+		if offset >= int64(len(t.partitions[partition])) {
+			continue
+		}
+		if !owned {
+			r.group.owner[partition] = r
+		}
+		r.group.position[partition] = offset + 1
+		r.next = partition + 1
+		msg := t.partitions[partition][offset]
+		msg.HighWaterMark = int64(len(t.partitions[partition]))
+		return msg, !owned, true
+	}
+	return kafka.Message{}, false, false
+}
+
+// CommitMessages records the offsets following msgs for the group
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	if r.groupID == "" {
+		return nil
+	}
+	r.fake.mu.Lock()
+	defer r.fake.mu.Unlock()
+	offsets, ok := r.fake.committed[r.groupID]
+	if !ok {
+		offsets = make(map[string]int64)
+		r.fake.committed[r.groupID] = offsets
+	}
+	for _, msg := range msgs {
+		key := partitionKey(msg.Topic, msg.Partition)
+		if next := msg.Offset + 1; next > offsets[key] {
+			offsets[key] = next
+		}
+	}
+	r.fake.notify()
+	return nil
+}
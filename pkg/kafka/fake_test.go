@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// produceN produces n keyless messages to topic with values "0".."n-1"
+func produceN(t *testing.T, client KafkaClient, topic string, n int) {
+	t.Helper()
+	for i := range n {
+		if err := client.Produce(context.Background(), topic, nil, []byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStartConsumerCommitsAndResumes(t *testing.T) {
+	fake := NewFakeKafkaClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	produceN(t, fake, "orders", 3)
+
+	var mu sync.Mutex
+	var seen []string
+	handler := func(_ context.Context, msg kafka.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, string(msg.Value))
+		return nil
+	}
+
+	c, err := fake.StartConsumer(ctx, "orders", "billing", handler, ConsumerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.WaitForCommit(ctx, "billing", "orders", 0, 3); err != nil {
+		t.Fatalf("offsets not committed: %v", err)
+	}
+	if err := c.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	produceN(t, fake, "orders", 5)
+	c, err = fake.StartConsumer(ctx, "orders", "billing", handler, ConsumerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.WaitForCommit(ctx, "billing", "orders", 0, 8); err != nil {
+		t.Fatalf("offsets not committed after restart: %v", err)
+	}
+	if err := c.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"0", "1", "2", "0", "1", "2", "3", "4"}
+	if len(seen) != len(want) {
+		t.Fatalf("handled %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("handled %v, want %v", seen, want)
+		}
+	}
+}
+
+func TestStartConsumerHandlerErrorStopsWithoutCommit(t *testing.T) {
+	fake := NewFakeKafkaClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	produceN(t, fake, "orders", 3)
+
+	failure := errors.New("invalid order")
+	c, err := fake.StartConsumer(ctx, "orders", "billing", func(_ context.Context, msg kafka.Message) error {
+		if msg.Offset == 1 {
+			return failure
+		}
+		return nil
+	}, ConsumerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.Done():
+	case <-ctx.Done():
+		t.Fatal("consumer did not stop after the handler failed")
+	}
+	if !errors.Is(c.Err(), failure) {
+		t.Fatalf("Err() = %v, want %v", c.Err(), failure)
+	}
+	if offset := fake.CommittedOffset("billing", "orders", 0); offset != 1 {
+		t.Fatalf("committed offset %d, want 1 so the failed message is redelivered", offset)
+	}
+}
+
+func TestStartConsumerGroupSplitsPartitions(t *testing.T) {
+	fake := NewFakeKafkaClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := fake.CreateTopics(ctx, TopicConfig{Name: "orders", Partitions: 4}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 40 {
+		if err := fake.Produce(ctx, "orders", []byte("key-"+strconv.Itoa(i)), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	owners := make(map[int]map[int]bool) // partition -> consumers that handled it
+	assigned := make(map[int][]int)
+	start := func(id int) Consumer {
+		c, err := fake.StartConsumer(ctx, "orders", "billing", func(_ context.Context, msg kafka.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if owners[msg.Partition] == nil {
+				owners[msg.Partition] = make(map[int]bool)
+			}
+			owners[msg.Partition][id] = true
+			return nil
+		}, ConsumerOptions{OnAssigned: func(_ context.Context, partitions []int) {
+			mu.Lock()
+			defer mu.Unlock()
+			assigned[id] = append(assigned[id], partitions...)
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	consumers := []Consumer{start(1), start(2)}
+	for partition := range 4 {
+		end := int64(len(fake.PartitionMessages("orders", partition)))
+		if err := fake.WaitForCommit(ctx, "billing", "orders", partition, end); err != nil {
+			t.Fatalf("partition %d not committed: %v", partition, err)
+		}
+	}
+	if len(fake.Consumers()) != 2 {
+		t.Fatalf("%d running consumers, want 2", len(fake.Consumers()))
+	}
+	if err := fake.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range consumers {
+		if c.Err() != nil {
+			t.Fatal(c.Err())
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var all []int
+	for partition, handledBy := range owners {
+		if len(handledBy) != 1 {
+			t.Errorf("partition %d handled by %d consumers", partition, len(handledBy))
+		}
+	}
+	for _, partitions := range assigned {
+		all = append(all, partitions...)
+	}
+	sort.Ints(all)
+	if len(all) != 4 || all[0] != 0 || all[3] != 3 {
+		t.Errorf("assigned partitions %v, want each of 0-3 once", all)
+	}
+}
+
+func TestStartConsumerAfterShutdown(t *testing.T) {
+	fake := NewFakeKafkaClient()
+	if err := fake.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.StartConsumer(context.Background(), "orders", "billing", func(context.Context, kafka.Message) error { return nil }, ConsumerOptions{}); err == nil {
+		t.Fatal("StartConsumer succeeded on a closed client")
+	}
+}
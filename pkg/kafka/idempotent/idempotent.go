@@ -0,0 +1,207 @@
+package idempotent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	kafkahelper "github.com/rk-the-dev/golib-core/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// ClaimStatus is the result of claiming a message ID
+type ClaimStatus int
+
+const (
+	// Claimed means the caller now owns the ID and must process the message
+	Claimed ClaimStatus = iota
+	// Duplicate means the message was already processed
+	Duplicate
+	// InProgress means another consumer holds an unexpired claim on the ID
+	InProgress
+)
+
+// ErrClaimLost is returned when a claim expired and another consumer took it over
+var ErrClaimLost = errors.New("idempotency claim lost")
+
+// Store records processed message IDs. Claim must be atomic: of concurrent
+// claims on the same ID only one may return Claimed.
+type Store interface {
+	// Claim marks id as being processed by token for lockTTL, unless it is
+	// already processed or claimed by someone else
+	Claim(ctx context.Context, id, token string, lockTTL time.Duration) (ClaimStatus, error)
+	// Extend keeps the claim of token on id for another lockTTL. It returns
+	// ErrClaimLost if token no longer holds the claim.
+	Extend(ctx context.Context, id, token string, lockTTL time.Duration) error
+	// Complete marks id as processed for ttl. It returns ErrClaimLost if token
+	// no longer holds the claim.
+	Complete(ctx context.Context, id, token string, ttl time.Duration) error
+	// Release drops the claim of token on id so a redelivery can process it
+	Release(ctx context.Context, id, token string) error
+}
+
+// KeyFunc derives the ID of a message
+type KeyFunc func(msg kafka.Message) string
+
+// Config configures an idempotent handler
+type Config struct {
+	Store   Store
+	TTL     time.Duration // How long processed IDs are remembered, defaults to 24h
+	LockTTL time.Duration // How long a claim lasts without renewal; it is renewed every LockTTL/3 while the handler runs. Defaults to 1m
+	KeyFunc KeyFunc       // Defaults to OffsetKey
+	// OnDuplicate is called for every skipped message
+	OnDuplicate func(ctx context.Context, msg kafka.Message)
+}
+
+// OffsetKey identifies a message by topic, partition, offset and a hash of its key
+func OffsetKey(msg kafka.Message) string {
+	h := fnv.New64a()
+	h.Write(msg.Key)
+	return msg.Topic + ":" + strconv.Itoa(msg.Partition) + ":" + strconv.FormatInt(msg.Offset, 10) + ":" + strconv.FormatUint(h.Sum64(), 16)
+}
+
+// HeaderKey identifies a message by the value of a header, e.g. a producer
+// assigned event ID, falling back to OffsetKey when the header is missing
+func HeaderKey(header string) KeyFunc {
+	return func(msg kafka.Message) string {
+		if value := kafkahelper.HeaderValue(msg, header); value != "" {
+			return header + ":" + value
+		}
+		return OffsetKey(msg)
+	}
+}
+
+// Wrap returns a handler that processes each message ID at most once within
+// the TTL. A duplicate that arrives while the original is still being handled
+// waits for it to finish. The claim is renewed while the handler runs; if it
+// is lost anyway, the handler's context is cancelled and the run fails with
+// ErrClaimLost. A failed handler releases its claim and returns the error so
+// the message is redelivered.
+func Wrap(handler kafkahelper.MessageHandler, cfg Config) (kafkahelper.MessageHandler, error) {
+	if handler == nil {
+		return nil, errors.New("idempotent handler requires a handler")
+	}
+	if cfg.Store == nil {
+		return nil, errors.New("idempotent handler requires a store")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = time.Minute
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = OffsetKey
+	}
+	poll := min(max(cfg.LockTTL/10, 100*time.Millisecond), time.Second)
+
+	return func(ctx context.Context, msg kafka.Message) error {
+		id := cfg.KeyFunc(msg)
+		token, err := newToken()
+		if err != nil {
+			return err
+		}
+		duplicate, err := claim(ctx, cfg.Store, id, token, cfg.LockTTL, poll)
+		if err != nil {
+			return err
+		}
+		if duplicate {
+			if cfg.OnDuplicate != nil {
+				cfg.OnDuplicate(ctx, msg)
+			}
+			return nil
+		}
+
+		handlerCtx, cancel := context.WithCancelCause(ctx)
+		stopRenewal := renew(handlerCtx, cancel, cfg.Store, id, token, cfg.LockTTL)
+		err = handler(handlerCtx, msg)
+		stopRenewal()
+		lost := errors.Is(context.Cause(handlerCtx), ErrClaimLost)
+		cancel(nil)
+		if lost {
+			return fmt.Errorf("failed to handle message %s: %w", id, ErrClaimLost)
+		}
+
+		if err != nil {
+			if releaseErr := cfg.Store.Release(context.WithoutCancel(ctx), id, token); releaseErr != nil {
+				fmt.Println("⚠️ Failed to release idempotency claim:", id, releaseErr)
+			}
+			return err
+		}
+		if err := cfg.Store.Complete(context.WithoutCancel(ctx), id, token, cfg.TTL); err != nil {
+			if errors.Is(err, ErrClaimLost) {
+				return fmt.Errorf("failed to mark message %s processed: %w", id, err)
+			}
+			// The message was handled; failing here would only cause it to be handled again
+			fmt.Println("⚠️ Failed to mark message processed:", id, err)
+		}
+		return nil
+	}, nil
+}
+
+// renew extends the claim every lockTTL/3 until the returned function is
+// called. When the claim is lost it cancels the handler with ErrClaimLost.
+func renew(ctx context.Context, cancel context.CancelCauseFunc, store Store, id, token string, lockTTL time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := store.Extend(context.WithoutCancel(ctx), id, token, lockTTL)
+			if errors.Is(err, ErrClaimLost) {
+				cancel(ErrClaimLost)
+				return
+			}
+			if err != nil {
+				// Keep the claim until it expires; the next renewal may succeed
+				fmt.Println("⚠️ Failed to renew idempotency claim:", id, err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// claim claims id, waiting while another consumer holds it. It reports true
+// when the message was already processed.
+func claim(ctx context.Context, store Store, id, token string, lockTTL, poll time.Duration) (bool, error) {
+	for {
+		status, err := store.Claim(ctx, id, token, lockTTL)
+		if err != nil {
+			return false, fmt.Errorf("failed to claim message %s: %w", id, err)
+		}
+		if status != InProgress {
+			return status == Duplicate, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// newToken returns a random claim token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate claim token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
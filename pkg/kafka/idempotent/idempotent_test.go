@@ -0,0 +1,303 @@
+package idempotent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rk-the-dev/golib-core/pkg/database/sqldialect"
+	kafkahelper "github.com/rk-the-dev/golib-core/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// sqliteExecutor adapts *sql.DB to sqldialect.Executor
+type sqliteExecutor struct {
+	db *sql.DB
+}
+
+func (e sqliteExecutor) ExecuteQuery(query string, args ...interface{}) (*sql.Rows, error) {
+	return e.db.Query(query, args...)
+}
+
+func (e sqliteExecutor) ExecuteNonQuery(query string, args ...interface{}) (sql.Result, error) {
+	return e.db.Exec(query, args...)
+}
+
+// stores returns one of each testable Store
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	memory, err := NewMemoryStore(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	sqlStore, err := NewSQLStore(sqliteExecutor{db}, "", sqldialect.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlStore.EnsureTable(); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Store{"memory": memory, "sql": sqlStore}
+}
+
+func TestStoreClaimLifecycle(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			steps := []struct {
+				name string
+				run  func() (ClaimStatus, error)
+				want ClaimStatus
+			}{
+				{"first claim", func() (ClaimStatus, error) { return store.Claim(ctx, "a", "t1", time.Minute) }, Claimed},
+				{"concurrent claim", func() (ClaimStatus, error) { return store.Claim(ctx, "a", "t2", time.Minute) }, InProgress},
+				{"claim after release", func() (ClaimStatus, error) {
+					if err := store.Release(ctx, "a", "t1"); err != nil {
+						return 0, err
+					}
+					return store.Claim(ctx, "a", "t2", time.Minute)
+				}, Claimed},
+				{"claim after complete", func() (ClaimStatus, error) {
+					if err := store.Complete(ctx, "a", "t2", time.Hour); err != nil {
+						return 0, err
+					}
+					return store.Claim(ctx, "a", "t3", time.Minute)
+				}, Duplicate},
+			}
+			for _, step := range steps {
+				got, err := step.run()
+				if err != nil {
+					t.Fatalf("%s: %v", step.name, err)
+				}
+				if got != step.want {
+					t.Fatalf("%s: status %d, want %d", step.name, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreRejectsStaleToken(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Claim(ctx, "a", "old", 50*time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			if status, err := store.Claim(ctx, "a", "new", time.Minute); err != nil || status != Claimed {
+				t.Fatalf("expired claim not taken over: %d %v", status, err)
+			}
+			if err := store.Extend(ctx, "a", "old", time.Minute); !errors.Is(err, ErrClaimLost) {
+				t.Fatalf("Extend with stale token = %v, want ErrClaimLost", err)
+			}
+			if err := store.Complete(ctx, "a", "old", time.Hour); !errors.Is(err, ErrClaimLost) {
+				t.Fatalf("Complete with stale token = %v, want ErrClaimLost", err)
+			}
+			if err := store.Extend(ctx, "a", "new", time.Minute); err != nil {
+				t.Fatalf("Extend by holder: %v", err)
+			}
+			if err := store.Complete(ctx, "a", "new", time.Hour); err != nil {
+				t.Fatalf("Complete by holder: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreClaimIsExclusive(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			var claimed atomic.Int32
+			var wg sync.WaitGroup
+			for i := range 20 {
+				wg.Add(1)
+				go func(token string) {
+					defer wg.Done()
+					if status, err := store.Claim(ctx, "a", token, time.Minute); err == nil && status == Claimed {
+						claimed.Add(1)
+					}
+				}(string(rune('a' + i)))
+			}
+			wg.Wait()
+			if n := claimed.Load(); n != 1 {
+				t.Fatalf("%d concurrent claims succeeded, want 1", n)
+			}
+		})
+	}
+}
+
+func TestWrapSkipsDuplicates(t *testing.T) {
+	fake := kafkahelper.NewFakeKafkaClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, id := range []string{"e1", "e2", "e1", "e3", "e2"} {
+		msg := kafka.Message{Topic: "payments", Value: []byte(id)}
+		kafkahelper.SetHeader(&msg, "event-id", id)
+		if err := fake.ProduceMessages(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := NewMemoryStore(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	handled := make(map[string]int)
+	var duplicates atomic.Int32
+	handler, err := Wrap(func(_ context.Context, msg kafka.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[string(msg.Value)]++
+		return nil
+	}, Config{Store: store, KeyFunc: HeaderKey("event-id"), OnDuplicate: func(context.Context, kafka.Message) {
+		duplicates.Add(1)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := fake.StartConsumer(ctx, "payments", "ledger", handler, kafkahelper.ConsumerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.WaitForCommit(ctx, "ledger", "payments", 0, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range []string{"e1", "e2", "e3"} {
+		if handled[id] != 1 {
+			t.Errorf("%s handled %d times, want 1", id, handled[id])
+		}
+	}
+	if n := duplicates.Load(); n != 2 {
+		t.Errorf("%d duplicates reported, want 2", n)
+	}
+}
+
+func TestWrapRedeliversFailedMessage(t *testing.T) {
+	store, err := NewMemoryStore(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int32
+	handler, err := Wrap(func(context.Context, kafka.Message) error {
+		if calls.Add(1) == 1 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}, Config{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := kafka.Message{Topic: "payments", Offset: 3}
+	if err := handler(context.Background(), msg); err == nil {
+		t.Fatal("first delivery succeeded, want the handler error")
+	}
+	for range 2 {
+		if err := handler(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler called %d times, want 2", n)
+	}
+}
+
+func TestWrapRenewsClaimDuringLongHandler(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			handler, err := Wrap(func(context.Context, kafka.Message) error {
+				calls.Add(1)
+				time.Sleep(400 * time.Millisecond)
+				return nil
+			}, Config{Store: store, LockTTL: 150 * time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wg sync.WaitGroup
+			for range 3 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := handler(context.Background(), kafka.Message{Topic: "payments", Offset: 1}); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := calls.Load(); n != 1 {
+				t.Fatalf("handler ran %d times although the claim was renewed, want 1", n)
+			}
+		})
+	}
+}
+
+// losingStore wraps a Store and loses every claim on the first renewal
+type losingStore struct {
+	Store
+}
+
+func (losingStore) Extend(context.Context, string, string, time.Duration) error {
+	return ErrClaimLost
+}
+
+func TestWrapFailsWhenClaimIsLost(t *testing.T) {
+	memory, err := NewMemoryStore(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := Wrap(func(ctx context.Context, _ kafka.Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Config{Store: losingStore{memory}, LockTTL: 60 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handler(context.Background(), kafka.Message{Topic: "payments"}); !errors.Is(err, ErrClaimLost) {
+		t.Fatalf("handler returned %v, want ErrClaimLost", err)
+	}
+}
+
+func TestWrapValidatesConfig(t *testing.T) {
+	store, err := NewMemoryStore(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noop := func(context.Context, kafka.Message) error { return nil }
+	tests := []struct {
+		name    string
+		handler kafkahelper.MessageHandler
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", noop, Config{Store: store}, false},
+		{"nil store", noop, Config{}, true},
+		{"nil handler", nil, Config{Store: store}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Wrap(tt.handler, tt.cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("Wrap error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
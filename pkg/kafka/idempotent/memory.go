@@ -0,0 +1,86 @@
+package idempotent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// memoryEntry is the state of an ID in a MemoryStore
+type memoryEntry struct {
+	token   string // Empty once processed
+	expires time.Time
+}
+
+// MemoryStore keeps IDs in a size-bounded LRU. It only deduplicates within one
+// process and forgets the oldest IDs once full.
+type MemoryStore struct {
+	cache *lru.Cache
+	mutex sync.Mutex
+}
+
+// NewMemoryStore creates a MemoryStore holding up to size IDs
+func NewMemoryStore(size int) (*MemoryStore, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryStore{cache: cache}, nil
+}
+
+// Claim claims id unless it is processed or claimed
+func (s *MemoryStore) Claim(_ context.Context, id, token string, lockTTL time.Duration) (ClaimStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if value, ok := s.cache.Get(id); ok {
+		entry := value.(memoryEntry)
+		if time.Now().Before(entry.expires) {
+			if entry.token == "" {
+				return Duplicate, nil
+			}
+			return InProgress, nil
+		}
+	}
+	s.cache.Add(id, memoryEntry{token: token, expires: time.Now().Add(lockTTL)})
+	return Claimed, nil
+}
+
+// Extend keeps the claim of token for another lockTTL
+func (s *MemoryStore) Extend(_ context.Context, id, token string, lockTTL time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.holds(id, token) {
+		return ErrClaimLost
+	}
+	s.cache.Add(id, memoryEntry{token: token, expires: time.Now().Add(lockTTL)})
+	return nil
+}
+
+// Complete marks id processed if token still holds the claim
+func (s *MemoryStore) Complete(_ context.Context, id, token string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.holds(id, token) {
+		return ErrClaimLost
+	}
+	s.cache.Add(id, memoryEntry{expires: time.Now().Add(ttl)})
+	return nil
+}
+
+// Release drops the claim if token still holds it
+func (s *MemoryStore) Release(_ context.Context, id, token string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.holds(id, token) {
+		s.cache.Remove(id)
+	}
+	return nil
+}
+
+// holds reports whether token holds the claim on id. The caller holds the mutex.
+func (s *MemoryStore) holds(id, token string) bool {
+	value, ok := s.cache.Peek(id)
+	return ok && value.(memoryEntry).token == token
+}
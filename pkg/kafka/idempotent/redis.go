@@ -0,0 +1,103 @@
+package idempotent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// processedValue marks a processed ID; claims hold "claim:<token>"
+const processedValue = "done"
+
+// claimScript sets a claim unless the key exists, returning the ClaimStatus
+var claimScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if not value then
+	redis.call('SET', KEYS[1], 'claim:' .. ARGV[1], 'PX', ARGV[2])
+	return 0
+end
+if value == ARGV[3] then
+	return 1
+end
+return 2
+`)
+
+// extendScript renews the key only if it still holds the caller's claim
+var extendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == 'claim:' .. ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// completeScript marks the key processed only if it still holds the caller's claim
+var completeScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == 'claim:' .. ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the key only if it still holds the caller's claim
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == 'claim:' .. ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisStore keeps IDs as Redis keys that expire with the TTL, so it
+// deduplicates across consumers and restarts
+type RedisStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore; keys are prefixed with prefix, e.g. "dedup:payments:"
+func NewRedisStore(client redis.Cmdable, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Claim atomically claims id unless it is processed or claimed
+func (s *RedisStore) Claim(ctx context.Context, id, token string, lockTTL time.Duration) (ClaimStatus, error) {
+	status, err := claimScript.Run(ctx, s.client, []string{s.prefix + id}, token, lockTTL.Milliseconds(), processedValue).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim %s in Redis: %w", id, err)
+	}
+	return ClaimStatus(status), nil
+}
+
+// Extend keeps the claim of token for another lockTTL
+func (s *RedisStore) Extend(ctx context.Context, id, token string, lockTTL time.Duration) error {
+	held, err := extendScript.Run(ctx, s.client, []string{s.prefix + id}, token, lockTTL.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to extend claim on %s in Redis: %w", id, err)
+	}
+	if held == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// Complete marks id processed if token still holds the claim
+func (s *RedisStore) Complete(ctx context.Context, id, token string, ttl time.Duration) error {
+	held, err := completeScript.Run(ctx, s.client, []string{s.prefix + id}, token, processedValue, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to mark %s processed in Redis: %w", id, err)
+	}
+	if held == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// Release drops the claim if token still holds it
+func (s *RedisStore) Release(ctx context.Context, id, token string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{s.prefix + id}, token).Err(); err != nil {
+		return fmt.Errorf("failed to release %s in Redis: %w", id, err)
+	}
+	return nil
+}
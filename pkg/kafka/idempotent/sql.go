@@ -0,0 +1,135 @@
+package idempotent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/database/sqldialect"
+)
+
+// SQLStore keeps IDs in a table with an expiry column. Expired rows are
+// reclaimed by Claim and can be removed with DeleteExpired.
+type SQLStore struct {
+	db      sqldialect.Executor
+	table   string
+	dialect sqldialect.Dialect
+}
+
+// NewSQLStore creates a SQLStore on table
+func NewSQLStore(db sqldialect.Executor, table string, dialect sqldialect.Dialect) (*SQLStore, error) {
+	if err := dialect.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid dedup store config: %w", err)
+	}
+	if table == "" {
+		table = "processed_messages"
+	}
+	return &SQLStore{db: db, table: table, dialect: dialect}, nil
+}
+
+// Schema returns the CREATE TABLE statement for the store table. A row with an
+// empty token is processed; otherwise it is claimed by that token.
+func (s *SQLStore) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + s.table + ` (
+	id VARCHAR(255) PRIMARY KEY,
+	token VARCHAR(64) NOT NULL,
+	expires_at ` + s.dialect.Timestamp() + ` NOT NULL
+)`
+}
+
+// EnsureTable creates the store table if it does not exist
+func (s *SQLStore) EnsureTable() error {
+	if err := sqldialect.EnsureTable(s.db, s.Schema()); err != nil {
+		return fmt.Errorf("failed to create dedup table: %w", err)
+	}
+	return nil
+}
+
+// Claim takes over an expired row or inserts a new one; each is a single
+// conditional statement, so only one concurrent claim succeeds
+func (s *SQLStore) Claim(_ context.Context, id, token string, lockTTL time.Duration) (ClaimStatus, error) {
+	now := time.Now().UTC()
+	expires := now.Add(lockTTL)
+
+	takeover := "UPDATE " + s.table + " SET token = " + s.dialect.Param(1) + ", expires_at = " + s.dialect.Param(2) +
+		" WHERE id = " + s.dialect.Param(3) + " AND expires_at < " + s.dialect.Param(4)
+	if claimed, err := s.affected(takeover, token, expires, id, now); err != nil || claimed {
+		return Claimed, err
+	}
+	insert := s.dialect.InsertIgnore(s.table, "id", "id", "token", "expires_at")
+	if claimed, err := s.affected(insert, id, token, expires); err != nil || claimed {
+		return Claimed, err
+	}
+
+	rows, err := s.db.ExecuteQuery("SELECT token FROM "+s.table+" WHERE id = "+s.dialect.Param(1), id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dedup row: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		// Deleted between the statements; the caller retries
+		return InProgress, rows.Err()
+	}
+	var current string
+	if err := rows.Scan(&current); err != nil {
+		return 0, fmt.Errorf("failed to read dedup row: %w", err)
+	}
+	if current == "" {
+		return Duplicate, nil
+	}
+	return InProgress, nil
+}
+
+// Extend keeps the claim of token for another lockTTL
+func (s *SQLStore) Extend(_ context.Context, id, token string, lockTTL time.Duration) error {
+	update := "UPDATE " + s.table + " SET expires_at = " + s.dialect.Param(1) +
+		" WHERE id = " + s.dialect.Param(2) + " AND token = " + s.dialect.Param(3)
+	return s.held(update, time.Now().UTC().Add(lockTTL), id, token)
+}
+
+// Complete marks id processed if token still holds the claim
+func (s *SQLStore) Complete(_ context.Context, id, token string, ttl time.Duration) error {
+	update := "UPDATE " + s.table + " SET token = '', expires_at = " + s.dialect.Param(1) +
+		" WHERE id = " + s.dialect.Param(2) + " AND token = " + s.dialect.Param(3)
+	return s.held(update, time.Now().UTC().Add(ttl), id, token)
+}
+
+// Release drops the claim if token still holds it
+func (s *SQLStore) Release(_ context.Context, id, token string) error {
+	query := "DELETE FROM " + s.table + " WHERE id = " + s.dialect.Param(1) + " AND token = " + s.dialect.Param(2)
+	if _, err := s.db.ExecuteNonQuery(query, id, token); err != nil {
+		return fmt.Errorf("failed to release dedup row: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes expired rows and returns how many were deleted
+func (s *SQLStore) DeleteExpired() (int64, error) {
+	result, err := s.db.ExecuteNonQuery("DELETE FROM "+s.table+" WHERE expires_at < "+s.dialect.Param(1), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired dedup rows: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// held runs an update guarded by the claim token, returning ErrClaimLost when
+// no row matched
+func (s *SQLStore) held(query string, args ...interface{}) error {
+	updated, err := s.affected(query, args...)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// affected runs a statement and reports whether it changed a row
+func (s *SQLStore) affected(query string, args ...interface{}) (bool, error) {
+	updated, err := sqldialect.Affected(s.db, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to update dedup row: %w", err)
+	}
+	return updated, nil
+}
@@ -3,15 +3,34 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
+	"github.com/rk-the-dev/golib-core/pkg/security"
 	"github.com/segmentio/kafka-go"
 )
 
+const defaultDialTimeout = 10 * time.Second
+
+// MessageHandler processes a consumed message. Returning an error leaves the
+// message uncommitted so it is redelivered.
+type MessageHandler func(ctx context.Context, message kafka.Message) error
+
 // KafkaClient defines the interface for Kafka producer & consumer
 type KafkaClient interface {
 	Produce(ctx context.Context, topic string, key, message []byte) error
-	Consume(ctx context.Context, topic string, groupID string, handler func(message kafka.Message)) error
+	// ProduceMessages sends fully built messages, including headers, in one write
+	ProduceMessages(ctx context.Context, messages ...kafka.Message) error
+	// Consume blocks, dispatching messages to handler until ctx is cancelled or the handler fails
+	Consume(ctx context.Context, topic string, groupID string, handler MessageHandler) error
+	// StartConsumer runs a consumer in the background until it is stopped, ctx is cancelled or the handler fails
+	StartConsumer(ctx context.Context, topic string, groupID string, handler MessageHandler, opts ConsumerOptions) (Consumer, error)
+	// Consumers returns the running consumers
+	Consumers() []Consumer
+	// Shutdown stops every consumer, letting in-flight messages finish until ctx is done, then closes the producer
+	Shutdown(ctx context.Context) error
 	Close() error
 }
 
@@ -19,38 +38,82 @@ type KafkaClient interface {
 type KafkaConfig struct {
 	Brokers   []string `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
 	ClientID  string   `env:"KAFKA_CLIENT_ID" envDefault:"golang-client"`
-	Partition int      `env:"KAFKA_PARTITION" envDefault:"0"`
+	Partition int      `env:"KAFKA_PARTITION" envDefault:"0"` // Partition read by Consume when groupID is empty
+
+	// Connection security
+	DialTimeout   time.Duration      `env:"KAFKA_DIAL_TIMEOUT" envDefault:"10s"`
+	SASLMechanism string             `env:"KAFKA_SASL_MECHANISM"` // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512; empty disables SASL
+	SASLUsername  string             `env:"KAFKA_SASL_USERNAME"`
+	SASLPassword  string             `env:"KAFKA_SASL_PASSWORD"`
+	TLS           security.TLSConfig `envPrefix:"KAFKA_"`
+
+	// Metrics receives producer and consumer metrics; nil disables them
+	Metrics metricshelper.MetricsHelper
+
+	// Consumer settings
+	ConsumerWorkers  int           `env:"KAFKA_CONSUMER_WORKERS" envDefault:"1"` // Messages with the same key always go to the same worker
+	ConsumerMinBytes int           `env:"KAFKA_CONSUMER_MIN_BYTES" envDefault:"10000"`
+	ConsumerMaxBytes int           `env:"KAFKA_CONSUMER_MAX_BYTES" envDefault:"10000000"`
+	ConsumerMaxWait  time.Duration `env:"KAFKA_CONSUMER_MAX_WAIT" envDefault:"1s"`
+	// ConsumerStartOffset is where a new consumer group starts reading: earliest or latest
+	ConsumerStartOffset string `env:"KAFKA_CONSUMER_START_OFFSET" envDefault:"earliest"`
+	// ConsumerDrainTimeout bounds how long Close waits for in-flight messages
+	ConsumerDrainTimeout time.Duration `env:"KAFKA_CONSUMER_DRAIN_TIMEOUT" envDefault:"10s"`
+
+	// Producer settings
+	RequiredAcks string        `env:"KAFKA_REQUIRED_ACKS" envDefault:"all"`    // all, one or none
+	Compression  string        `env:"KAFKA_COMPRESSION" envDefault:"none"`     // none, gzip, snappy, lz4 or zstd
+	Balancer     string        `env:"KAFKA_BALANCER" envDefault:"least_bytes"` // least_bytes, hash, round_robin, crc32 or murmur2
+	BatchSize    int           `env:"KAFKA_BATCH_SIZE" envDefault:"100"`       // Messages buffered per partition before a write
+	BatchBytes   int64         `env:"KAFKA_BATCH_BYTES" envDefault:"1048576"`  // Maximum size of a write request
+	BatchTimeout time.Duration `env:"KAFKA_BATCH_TIMEOUT" envDefault:"1s"`     // Flush incomplete batches at least this often
+	MaxAttempts  int           `env:"KAFKA_MAX_ATTEMPTS" envDefault:"10"`      // Delivery attempts before a write fails
 }
 
 // kafkaClient implements KafkaClient
 type kafkaClient struct {
+	cfg    KafkaConfig
+	dialer *kafka.Dialer
 	writer *kafka.Writer
-	reader *kafka.Reader
+
+	consumers consumerRegistry
 }
 
 var (
 	instance *kafkaClient
-	once     sync.Once
+	mu       sync.Mutex
 )
 
-// NewKafkaClient initializes and returns a Kafka producer
-func NewKafkaClient(cfg *KafkaConfig) KafkaClient {
-	once.Do(func() {
-		instance = &kafkaClient{
-			writer: &kafka.Writer{
-				Addr:     kafka.TCP(cfg.Brokers...),
-				Balancer: &kafka.LeastBytes{},
-			},
-		}
-		fmt.Println("✅ Kafka client initialized:", cfg.Brokers)
-	})
-
-	return instance
+// NewKafkaClient initializes and returns a Kafka client. SASL and TLS
+// settings apply to both the producer and the consumers. An invalid config is
+// not cached, so a later call retries.
+func NewKafkaClient(cfg *KafkaConfig) (KafkaClient, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if instance != nil {
+		return instance, nil
+	}
+	writer, err := newWriter(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kafka producer config: %w", err)
+	}
+	dialer, err := newDialer(*cfg)
+	if err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("invalid kafka consumer config: %w", err)
+	}
+	instance = &kafkaClient{
+		cfg:    *cfg,
+		dialer: dialer,
+		writer: writer,
+	}
+	fmt.Println("✅ Kafka client initialized:", cfg.Brokers)
+	return instance, nil
 }
 
 // Produce sends a message to Kafka
 func (k *kafkaClient) Produce(ctx context.Context, topic string, key, message []byte) error {
-	err := k.writer.WriteMessages(ctx, kafka.Message{
+	err := k.write(ctx, kafka.Message{
 		Topic: topic,
 		Key:   key,
 		Value: message,
@@ -58,39 +121,80 @@ func (k *kafkaClient) Produce(ctx context.Context, topic string, key, message []
 	if err != nil {
 		return fmt.Errorf("failed to send message to Kafka: %w", err)
 	}
-	fmt.Println("✅ Message sent to Kafka:", string(message))
 	return nil
 }
 
-// Consume reads messages from Kafka and processes them using the provided handler
-func (k *kafkaClient) Consume(ctx context.Context, topic, groupID string, handler func(message kafka.Message)) error {
-	k.reader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  []string{"localhost:9092"},
-		Topic:    topic,
-		GroupID:  groupID,
-		MinBytes: 10e3, // 10KB
-		MaxBytes: 10e6, // 10MB
-	})
+// ProduceMessages sends messages with their topics, keys and headers to Kafka
+func (k *kafkaClient) ProduceMessages(ctx context.Context, messages ...kafka.Message) error {
+	if err := k.write(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to send messages to Kafka: %w", err)
+	}
+	return nil
+}
 
-	fmt.Println("🔄 Consuming messages from topic:", topic)
+// write sends messages and records producer metrics
+func (k *kafkaClient) write(ctx context.Context, messages ...kafka.Message) error {
+	start := time.Now()
+	err := k.writer.WriteMessages(ctx, messages...)
+	observeProduce(k.cfg.Metrics, "", messages, time.Since(start), err)
+	return err
+}
 
-	for {
-		msg, err := k.reader.ReadMessage(ctx)
-		if err != nil {
-			return fmt.Errorf("error reading message from Kafka: %w", err)
-		}
-		handler(msg) // Process the message using the custom handler
+// Consume reads messages from Kafka and processes them with a pool of
+// ConsumerWorkers. Ordering is preserved per key, and offsets are committed
+// only once every earlier message of the partition was handled successfully.
+// It returns nil when ctx is cancelled and the handler error otherwise.
+func (k *kafkaClient) Consume(ctx context.Context, topic, groupID string, handler MessageHandler) error {
+	c, err := k.StartConsumer(ctx, topic, groupID, handler, ConsumerOptions{})
+	if err != nil {
+		return err
 	}
+	<-c.Done()
+	return c.Err()
 }
 
-// Close closes Kafka producer & consumer connections
-func (k *kafkaClient) Close() error {
-	if k.writer != nil {
-		k.writer.Close()
+// readerConfig builds the reader configuration for a topic and consumer group
+func (k *kafkaClient) readerConfig(topic, groupID string) kafka.ReaderConfig {
+	minBytes := k.cfg.ConsumerMinBytes
+	if minBytes <= 0 {
+		minBytes = 10e3 // 10KB
 	}
-	if k.reader != nil {
-		k.reader.Close()
+	maxBytes := k.cfg.ConsumerMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 10e6 // 10MB
 	}
-	fmt.Println("🔻 Kafka client closed")
-	return nil
+	readerCfg := kafka.ReaderConfig{
+		Brokers:     k.cfg.Brokers,
+		Dialer:      k.dialer,
+		Topic:       topic,
+		GroupID:     groupID,
+		MinBytes:    minBytes,
+		MaxBytes:    maxBytes,
+		MaxWait:     k.cfg.ConsumerMaxWait,
+		StartOffset: k.startOffset(),
+	}
+	if groupID == "" {
+		readerCfg.Partition = k.cfg.Partition
+	}
+	return readerCfg
+}
+
+// startOffset returns where a new consumer group starts reading
+func (k *kafkaClient) startOffset() int64 {
+	if strings.EqualFold(k.cfg.ConsumerStartOffset, "latest") {
+		return kafka.LastOffset
+	}
+	return kafka.FirstOffset
+}
+
+// Close closes Kafka producer & consumer connections, waiting up to
+// ConsumerDrainTimeout for in-flight messages
+func (k *kafkaClient) Close() error {
+	timeout := k.cfg.ConsumerDrainTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return k.Shutdown(ctx)
 }
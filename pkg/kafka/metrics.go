@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
+	"github.com/segmentio/kafka-go"
+)
+
+// Metrics reported through KafkaConfig.Metrics. Producer metrics are labelled
+// by topic, consumer metrics by topic and group, and lag also by partition.
+const (
+	MetricProduced          = "kafka_messages_produced_total"
+	MetricProduceErrors     = "kafka_produce_errors_total"
+	MetricProduceDuration   = "kafka_produce_duration_seconds"
+	MetricConsumed          = "kafka_messages_consumed_total"
+	MetricHandlerErrors     = "kafka_handler_errors_total"
+	MetricHandleDuration    = "kafka_handle_duration_seconds"
+	MetricMessagesPerSecond = "kafka_consumer_messages_per_second"
+	MetricCommitErrors      = "kafka_commit_errors_total"
+	MetricConsumerLag       = "kafka_consumer_lag"
+)
+
+// rateInterval is how often the consumer throughput gauge is updated
+const rateInterval = 10 * time.Second
+
+// observeProduce records the outcome of a write. Messages without a topic are
+// counted under defaultTopic; a zero duration skips the latency histogram.
+func observeProduce(metrics metricshelper.MetricsHelper, defaultTopic string, messages []kafka.Message, duration time.Duration, err error) {
+	if metrics == nil {
+		return
+	}
+	counts := make(map[string]int)
+	for _, msg := range messages {
+		topic := msg.Topic
+		if topic == "" {
+			topic = defaultTopic
+		}
+		counts[topic]++
+	}
+	name := MetricProduced
+	if err != nil {
+		name = MetricProduceErrors
+	}
+	for topic, n := range counts {
+		labels := map[string]string{"topic": topic}
+		metricshelper.AddCounter(metrics, name, n, labels)
+		if duration > 0 {
+			metrics.ObserveHistogram(MetricProduceDuration, duration.Seconds(), labels)
+		}
+	}
+}
+
+// consumerMetrics reports the metrics of one consumer. A nil *consumerMetrics does nothing.
+type consumerMetrics struct {
+	metrics metricshelper.MetricsHelper
+	topic   string
+	group   string
+	labels  map[string]string
+	handled atomic.Int64
+}
+
+// newConsumerMetrics returns nil when metrics is nil
+func newConsumerMetrics(metrics metricshelper.MetricsHelper, topic, group string) *consumerMetrics {
+	if metrics == nil {
+		return nil
+	}
+	return &consumerMetrics{
+		metrics: metrics,
+		topic:   topic,
+		group:   group,
+		labels:  map[string]string{"topic": topic, "group": group},
+	}
+}
+
+// fetched sets the partition lag from the high water mark of a fetched message
+func (m *consumerMetrics) fetched(msg kafka.Message) {
+	if m == nil || msg.HighWaterMark <= 0 {
+		return
+	}
+	labels := map[string]string{"topic": m.topic, "group": m.group, "partition": strconv.Itoa(msg.Partition)}
+	m.metrics.SetGauge(MetricConsumerLag, float64(max(msg.HighWaterMark-msg.Offset-1, 0)), labels)
+}
+
+// handle records a handler call
+func (m *consumerMetrics) handle(duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.metrics.ObserveHistogram(MetricHandleDuration, duration.Seconds(), m.labels)
+	if err != nil {
+		m.metrics.IncrementCounter(MetricHandlerErrors, m.labels)
+		return
+	}
+	m.handled.Add(1)
+	m.metrics.IncrementCounter(MetricConsumed, m.labels)
+}
+
+// commitFailed records a failed offset commit
+func (m *consumerMetrics) commitFailed() {
+	if m == nil {
+		return
+	}
+	m.metrics.IncrementCounter(MetricCommitErrors, m.labels)
+}
+
+// reportRate updates the throughput gauge every rateInterval until done is closed
+func (m *consumerMetrics) reportRate(done <-chan struct{}) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(rateInterval)
+	defer ticker.Stop()
+	last, lastTime := m.handled.Load(), time.Now()
+	for {
+		select {
+		case <-done:
+			m.metrics.SetGauge(MetricMessagesPerSecond, 0, m.labels)
+			return
+		case now := <-ticker.C:
+			handled := m.handled.Load()
+			m.metrics.SetGauge(MetricMessagesPerSecond, float64(handled-last)/now.Sub(lastTime).Seconds(), m.labels)
+			last, lastTime = handled, now
+		}
+	}
+}
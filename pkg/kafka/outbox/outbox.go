@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/database/sqldialect"
+	"github.com/segmentio/kafka-go"
+)
+
+// Config configures an Outbox
+type Config struct {
+	Table   string             `env:"OUTBOX_TABLE" envDefault:"outbox_events"`
+	Dialect sqldialect.Dialect `env:"OUTBOX_DIALECT" envDefault:"postgres"`
+}
+
+// Outbox stores Kafka messages in a table so they are written in the same
+// transaction as the business data and published later by a Relay
+type Outbox struct {
+	table   string
+	dialect sqldialect.Dialect
+}
+
+// NewOutbox creates an Outbox for the configured table and dialect
+func NewOutbox(cfg Config) (*Outbox, error) {
+	if cfg.Table == "" {
+		cfg.Table = "outbox_events"
+	}
+	if cfg.Dialect == "" {
+		cfg.Dialect = sqldialect.Postgres
+	}
+	if err := cfg.Dialect.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid outbox config: %w", err)
+	}
+	return &Outbox{table: cfg.Table, dialect: cfg.Dialect}, nil
+}
+
+// Table returns the outbox table name
+func (o *Outbox) Table() string {
+	return o.table
+}
+
+// Schema returns the statements that create the outbox table and the index
+// the relay uses to find unsent rows
+func (o *Outbox) Schema() string {
+	return strings.Join(o.statements(), ";\n")
+}
+
+// EnsureTable creates the outbox table and its index if they do not exist
+func (o *Outbox) EnsureTable(db sqldialect.TxRunner) error {
+	return db.WithTransaction(func(tx *sql.Tx) error {
+		for _, statement := range o.statements() {
+			if _, err := tx.Exec(statement); err != nil {
+				return fmt.Errorf("failed to create outbox table: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// statements returns the schema statements for the dialect. MySQL has no
+// partial indexes, so it indexes (sent_at, id) inline; the others index id
+// over unsent rows only.
+func (o *Outbox) statements() []string {
+	index := "CREATE INDEX IF NOT EXISTS idx_" + o.table + "_unsent ON " + o.table + " (id) WHERE sent_at IS NULL"
+	switch o.dialect {
+	case sqldialect.MySQL:
+		return []string{"CREATE TABLE IF NOT EXISTS " + o.table + ` (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	msg_key VARBINARY(1024),
+	payload LONGBLOB,
+	headers TEXT,
+	created_at DATETIME(6) NOT NULL,
+	sent_at DATETIME(6) NULL,
+	INDEX idx_` + o.table + `_unsent (sent_at, id)
+)`}
+	case sqldialect.SQLite:
+		return []string{"CREATE TABLE IF NOT EXISTS " + o.table + ` (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic TEXT NOT NULL,
+	msg_key BLOB,
+	payload BLOB,
+	headers TEXT,
+	created_at TIMESTAMP NOT NULL,
+	sent_at TIMESTAMP NULL
+)`, index}
+	default:
+		return []string{"CREATE TABLE IF NOT EXISTS " + o.table + ` (
+	id BIGSERIAL PRIMARY KEY,
+	topic TEXT NOT NULL,
+	msg_key BYTEA,
+	payload BYTEA,
+	headers TEXT,
+	created_at TIMESTAMPTZ NOT NULL,
+	sent_at TIMESTAMPTZ NULL
+)`, index}
+	}
+}
+
+// Enqueue inserts messages into the outbox using the caller's transaction.
+// Each message needs a Topic; Key, Value and Headers are stored as given.
+func (o *Outbox) Enqueue(ctx context.Context, tx *sql.Tx, messages ...kafka.Message) error {
+	query := "INSERT INTO " + o.table + " (topic, msg_key, payload, headers, created_at) VALUES (" + o.dialect.Params(1, 5) + ")"
+	now := time.Now().UTC()
+	for _, msg := range messages {
+		if msg.Topic == "" {
+			return fmt.Errorf("outbox message topic cannot be empty")
+		}
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return fmt.Errorf("failed to encode outbox headers: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, msg.Topic, msg.Key, msg.Value, string(headers), now); err != nil {
+			return fmt.Errorf("failed to insert outbox message: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rk-the-dev/golib-core/pkg/database/sqldialect"
+	kafkahelper "github.com/rk-the-dev/golib-core/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// sqliteRunner adapts *sql.DB to sqldialect.TxRunner
+type sqliteRunner struct {
+	db *sql.DB
+}
+
+func (r sqliteRunner) WithTransaction(txFunc func(*sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := txFunc(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// newTestOutbox returns an outbox on a fresh in-memory SQLite database
+func newTestOutbox(t *testing.T) (*Outbox, sqliteRunner) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	o, err := NewOutbox(Config{Dialect: sqldialect.SQLite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := sqliteRunner{db}
+	if err := o.EnsureTable(runner); err != nil {
+		t.Fatal(err)
+	}
+	return o, runner
+}
+
+// enqueue stores messages for topic with the values v0..v(n-1) in one transaction
+func enqueue(t *testing.T, o *Outbox, runner sqliteRunner, topic string, n int) {
+	t.Helper()
+	err := runner.WithTransaction(func(tx *sql.Tx) error {
+		for i := range n {
+			msg := kafka.Message{
+				Topic:   topic,
+				Key:     []byte("order-1"),
+				Value:   []byte(fmt.Sprintf("v%d", i)),
+				Headers: []kafka.Header{{Key: "event-id", Value: []byte(fmt.Sprint(i))}},
+			}
+			if err := o.Enqueue(context.Background(), tx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// count returns the number of outbox rows matching where
+func count(t *testing.T, runner sqliteRunner, where string) int {
+	t.Helper()
+	var n int
+	if err := runner.db.QueryRow("SELECT COUNT(*) FROM outbox_events WHERE " + where).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestEnsureTableCreatesUnsentIndex(t *testing.T) {
+	o, runner := newTestOutbox(t)
+	if err := o.EnsureTable(runner); err != nil {
+		t.Fatalf("second EnsureTable: %v", err)
+	}
+	var schema string
+	err := runner.db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'index' AND name = 'idx_outbox_events_unsent'").Scan(&schema)
+	if err != nil {
+		t.Fatalf("unsent index not found: %v", err)
+	}
+	if !strings.Contains(schema, "WHERE sent_at IS NULL") {
+		t.Errorf("unsent index is not partial: %s", schema)
+	}
+}
+
+func TestEnqueueUsesCallerTransaction(t *testing.T) {
+	o, runner := newTestOutbox(t)
+	rollback := errors.New("order rejected")
+	err := runner.WithTransaction(func(tx *sql.Tx) error {
+		if err := o.Enqueue(context.Background(), tx, kafka.Message{Topic: "orders", Value: []byte("v")}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("got %v, want the rollback error", err)
+	}
+	if n := count(t, runner, "1 = 1"); n != 0 {
+		t.Fatalf("%d rows after rollback, want 0", n)
+	}
+
+	enqueue(t, o, runner, "orders", 2)
+	if n := count(t, runner, "sent_at IS NULL"); n != 2 {
+		t.Fatalf("%d unsent rows after commit, want 2", n)
+	}
+	err = runner.WithTransaction(func(tx *sql.Tx) error {
+		return o.Enqueue(context.Background(), tx, kafka.Message{Value: []byte("no topic")})
+	})
+	if err == nil {
+		t.Error("message without a topic was enqueued")
+	}
+}
+
+func TestRelayPublishesAndMarksSent(t *testing.T) {
+	o, runner := newTestOutbox(t)
+	client := kafkahelper.NewFakeKafkaClient()
+	enqueue(t, o, runner, "orders", 5)
+	relay := NewRelay(runner, client, o, RelayConfig{BatchSize: 2})
+
+	if err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	messages := client.Messages("orders")
+	if len(messages) != 5 {
+		t.Fatalf("published %d messages, want 5", len(messages))
+	}
+	for i, msg := range messages {
+		if string(msg.Value) != fmt.Sprintf("v%d", i) || string(msg.Key) != "order-1" {
+			t.Errorf("message %d: key %q value %q", i, msg.Key, msg.Value)
+		}
+		want := []kafka.Header{{Key: "event-id", Value: []byte(fmt.Sprint(i))}}
+		if !reflect.DeepEqual(msg.Headers, want) {
+			t.Errorf("message %d headers %v, want %v", i, msg.Headers, want)
+		}
+	}
+	if n := count(t, runner, "sent_at IS NULL"); n != 0 {
+		t.Fatalf("%d rows left unsent", n)
+	}
+
+	if err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(client.Messages("orders")); n != 5 {
+		t.Errorf("second drain republished: %d messages", n)
+	}
+}
+
+func TestRelayKeepsRowsWhenPublishFails(t *testing.T) {
+	o, runner := newTestOutbox(t)
+	client := kafkahelper.NewFakeKafkaClient()
+	enqueue(t, o, runner, "orders", 3)
+	relay := NewRelay(runner, client, o, RelayConfig{})
+
+	client.SetProduceError(errors.New("broker unavailable"))
+	if _, err := relay.PublishBatch(context.Background()); err == nil {
+		t.Fatal("PublishBatch succeeded while the broker was down")
+	}
+	if n := count(t, runner, "sent_at IS NULL"); n != 3 {
+		t.Fatalf("%d unsent rows after a failed publish, want 3", n)
+	}
+
+	client.SetProduceError(nil)
+	published, err := relay.PublishBatch(context.Background())
+	if err != nil || published != 3 {
+		t.Fatalf("published %d messages, err %v; want 3", published, err)
+	}
+}
+
+func TestRelayPurgesExpiredRows(t *testing.T) {
+	o, runner := newTestOutbox(t)
+	client := kafkahelper.NewFakeKafkaClient()
+	enqueue(t, o, runner, "orders", 4)
+	relay := NewRelay(runner, client, o, RelayConfig{Retention: 24 * time.Hour})
+	if err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, runner, "1 = 1"); n != 4 {
+		t.Fatalf("%d rows after publishing, want 4 kept within retention", n)
+	}
+
+	// Age two sent rows past the retention and add one unsent row
+	if _, err := runner.db.Exec("UPDATE outbox_events SET sent_at = ? WHERE id <= 2", time.Now().UTC().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	enqueue(t, o, runner, "orders", 1)
+	if err := relay.purge(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, runner, "id <= 2"); n != 0 {
+		t.Errorf("%d expired rows kept", n)
+	}
+	if n := count(t, runner, "1 = 1"); n != 3 {
+		t.Errorf("%d rows left, want the 2 recent and 1 unsent", n)
+	}
+}
@@ -0,0 +1,225 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/database/sqldialect"
+	kafkahelper "github.com/rk-the-dev/golib-core/pkg/kafka"
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
+	"github.com/segmentio/kafka-go"
+)
+
+// Metrics reported by the relay, labelled by table
+const (
+	MetricPublished       = "outbox_messages_published_total"
+	MetricPublishErrors   = "outbox_publish_errors_total"
+	MetricPending         = "outbox_pending_messages"
+	MetricLagSeconds      = "outbox_lag_seconds"
+	MetricDeliverySeconds = "outbox_delivery_seconds"
+)
+
+// RelayConfig configures a Relay
+type RelayConfig struct {
+	PollInterval time.Duration // Wait between polls when the outbox is drained, defaults to 1s
+	BatchSize    int           // Messages locked and published per transaction, defaults to 100
+	// Retention deletes sent rows older than this; zero keeps them
+	Retention time.Duration
+	// Metrics receives publish counts, pending count and lag; nil disables metrics
+	Metrics metricshelper.MetricsHelper
+}
+
+// Relay polls the outbox and publishes unsent messages through a KafkaClient.
+// Rows are locked with FOR UPDATE SKIP LOCKED so several relays can run side
+// by side. A row is marked sent in the same transaction that locked it, after
+// Kafka acknowledged it, so delivery is at-least-once: a crash between the
+// publish and the commit republishes the batch.
+type Relay struct {
+	db     sqldialect.TxRunner
+	client kafkahelper.KafkaClient
+	outbox *Outbox
+	cfg    RelayConfig
+	labels map[string]string
+}
+
+// outboxRow is an unsent outbox message
+type outboxRow struct {
+	id        int64
+	message   kafka.Message
+	createdAt time.Time
+}
+
+// NewRelay creates a Relay for the outbox
+func NewRelay(db sqldialect.TxRunner, client kafkahelper.KafkaClient, outbox *Outbox, cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Relay{db: db, client: client, outbox: outbox, cfg: cfg, labels: map[string]string{"table": outbox.table}}
+}
+
+// Run publishes outbox messages until ctx is cancelled. Failed polls are
+// logged and retried on the next interval.
+func (r *Relay) Run(ctx context.Context) error {
+	fmt.Println("🔄 Outbox relay started for table:", r.outbox.table)
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			fmt.Println("❌ Outbox relay failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Println("🔻 Outbox relay stopped for table:", r.outbox.table)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes batches until no unsent messages remain, then reports lag
+// and purges expired rows
+func (r *Relay) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		published, err := r.PublishBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if published < r.cfg.BatchSize {
+			break
+		}
+	}
+	if err := r.reportLag(ctx); err != nil {
+		return err
+	}
+	return r.purge(ctx)
+}
+
+// PublishBatch locks up to BatchSize unsent rows, publishes them and marks
+// them sent. It returns the number of messages published.
+func (r *Relay) PublishBatch(ctx context.Context) (int, error) {
+	var rows []outboxRow
+	err := r.db.WithTransaction(func(tx *sql.Tx) error {
+		var err error
+		if rows, err = r.lockUnsent(ctx, tx); err != nil || len(rows) == 0 {
+			return err
+		}
+		messages := make([]kafka.Message, len(rows))
+		for i, row := range rows {
+			messages[i] = row.message
+		}
+		if err := r.client.ProduceMessages(ctx, messages...); err != nil {
+			r.count(MetricPublishErrors, len(rows))
+			return err
+		}
+		return r.markSent(ctx, tx, rows)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish outbox batch: %w", err)
+	}
+	r.count(MetricPublished, len(rows))
+	if r.cfg.Metrics != nil {
+		now := time.Now()
+		for _, row := range rows {
+			r.cfg.Metrics.ObserveHistogram(MetricDeliverySeconds, now.Sub(row.createdAt).Seconds(), r.labels)
+		}
+	}
+	return len(rows), nil
+}
+
+// lockUnsent selects and locks the oldest unsent rows
+func (r *Relay) lockUnsent(ctx context.Context, tx *sql.Tx) ([]outboxRow, error) {
+	query := "SELECT id, topic, msg_key, payload, headers, created_at FROM " + r.outbox.table +
+		" WHERE sent_at IS NULL ORDER BY id LIMIT " + fmt.Sprint(r.cfg.BatchSize) + r.outbox.dialect.SkipLocked()
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var result []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		var headers sql.NullString
+		if err := rows.Scan(&row.id, &row.message.Topic, &row.message.Key, &row.message.Value, &headers, &row.createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		if headers.Valid && headers.String != "" {
+			if err := json.Unmarshal([]byte(headers.String), &row.message.Headers); err != nil {
+				return nil, fmt.Errorf("failed to decode headers of outbox message %d: %w", row.id, err)
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// markSent sets sent_at on the published rows
+func (r *Relay) markSent(ctx context.Context, tx *sql.Tx, rows []outboxRow) error {
+	args := make([]any, 0, len(rows)+1)
+	args = append(args, time.Now().UTC())
+	for _, row := range rows {
+		args = append(args, row.id)
+	}
+	query := "UPDATE " + r.outbox.table + " SET sent_at = " + r.outbox.dialect.Params(1, 1) +
+		" WHERE id IN (" + r.outbox.dialect.Params(2, len(rows)) + ")"
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to mark outbox messages sent: %w", err)
+	}
+	return nil
+}
+
+// reportLag sets the pending count and the age of the oldest unsent message
+func (r *Relay) reportLag(ctx context.Context) error {
+	if r.cfg.Metrics == nil {
+		return nil
+	}
+	return r.db.WithTransaction(func(tx *sql.Tx) error {
+		var pending int64
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+r.outbox.table+" WHERE sent_at IS NULL").Scan(&pending); err != nil {
+			return fmt.Errorf("failed to count outbox messages: %w", err)
+		}
+		lag := 0.0
+		if pending > 0 {
+			var oldest time.Time
+			query := "SELECT created_at FROM " + r.outbox.table + " WHERE sent_at IS NULL ORDER BY id LIMIT 1"
+			err := tx.QueryRowContext(ctx, query).Scan(&oldest)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to read outbox lag: %w", err)
+			}
+			if err == nil {
+				lag = time.Since(oldest).Seconds()
+			}
+		}
+		r.cfg.Metrics.SetGauge(MetricPending, float64(pending), r.labels)
+		r.cfg.Metrics.SetGauge(MetricLagSeconds, lag, r.labels)
+		return nil
+	})
+}
+
+// purge deletes sent rows older than the retention
+func (r *Relay) purge(ctx context.Context) error {
+	if r.cfg.Retention <= 0 {
+		return nil
+	}
+	return r.db.WithTransaction(func(tx *sql.Tx) error {
+		query := "DELETE FROM " + r.outbox.table + " WHERE sent_at IS NOT NULL AND sent_at < " + r.outbox.dialect.Params(1, 1)
+		if _, err := tx.ExecContext(ctx, query, time.Now().UTC().Add(-r.cfg.Retention)); err != nil {
+			return fmt.Errorf("failed to purge outbox messages: %w", err)
+		}
+		return nil
+	})
+}
+
+// count adds the number of messages to a counter
+func (r *Relay) count(name string, n int) {
+	if r.cfg.Metrics == nil {
+		return
+	}
+	metricshelper.AddCounter(r.cfg.Metrics, name, n, r.labels)
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rk-the-dev/golib-core/pkg/metricshelper"
+	"github.com/segmentio/kafka-go"
+)
+
+// Record is a typed message for Producer.SendBatch
+type Record[T any] struct {
+	Key     []byte
+	Value   T
+	Headers []kafka.Header
+}
+
+// ProducerOptions configures a Producer
+type ProducerOptions struct {
+	// Async returns from Send as soon as messages are buffered; failures are
+	// only reported through OnDelivery
+	Async bool
+	// OnDelivery is called with each written batch and its error, in sync and async mode
+	OnDelivery func(messages []kafka.Message, err error)
+	// Headers returns headers added to every message, e.g. trace context.
+	// Defaults to HeadersFromContext.
+	Headers func(ctx context.Context) []kafka.Header
+}
+
+// Producer writes values of type T to a topic using a Serializer
+type Producer[T any] struct {
+	topic      string
+	serializer Serializer[T]
+	headers    func(ctx context.Context) []kafka.Header
+	writer     *kafka.Writer
+	metrics    metricshelper.MetricsHelper // Only set for sync writes; async outcomes are recorded on completion
+}
+
+// NewProducer creates a Producer for topic with the producer settings in cfg
+func NewProducer[T any](cfg *KafkaConfig, topic string, serializer Serializer[T], opts ProducerOptions) (*Producer[T], error) {
+	if topic == "" {
+		return nil, fmt.Errorf("producer topic cannot be empty")
+	}
+	writer, err := newWriter(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	writer.Topic = topic
+	writer.Async = opts.Async
+	writer.Completion = opts.OnDelivery
+	if opts.Async && cfg.Metrics != nil {
+		// Async writes return before delivery, so outcomes are only known here
+		writer.Completion = func(messages []kafka.Message, err error) {
+			observeProduce(cfg.Metrics, topic, messages, 0, err)
+			if opts.OnDelivery != nil {
+				opts.OnDelivery(messages, err)
+			}
+		}
+	}
+	if opts.Headers == nil {
+		opts.Headers = HeadersFromContext
+	}
+	p := &Producer[T]{topic: topic, serializer: serializer, headers: opts.Headers, writer: writer}
+	if !opts.Async {
+		p.metrics = cfg.Metrics
+	}
+	return p, nil
+}
+
+// Send serializes and writes a single value
+func (p *Producer[T]) Send(ctx context.Context, key []byte, value T, headers ...kafka.Header) error {
+	return p.SendBatch(ctx, Record[T]{Key: key, Value: value, Headers: headers})
+}
+
+// SendBatch serializes and writes records in one call. Nothing is written if
+// any record fails to serialize.
+func (p *Producer[T]) SendBatch(ctx context.Context, records ...Record[T]) error {
+	ctxHeaders := p.headers(ctx)
+	messages := make([]kafka.Message, len(records))
+	for i, record := range records {
+		value, err := p.serializer.Serialize(ctx, p.topic, record.Value)
+		if err != nil {
+			return fmt.Errorf("failed to serialize message for %s: %w", p.topic, err)
+		}
+		headers := make([]kafka.Header, 0, len(ctxHeaders)+len(record.Headers))
+		headers = append(headers, ctxHeaders...)
+		messages[i] = kafka.Message{Key: record.Key, Value: value, Headers: append(headers, record.Headers...)}
+	}
+	start := time.Now()
+	err := p.writer.WriteMessages(ctx, messages...)
+	observeProduce(p.metrics, p.topic, messages, time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to send messages to %s: %w", p.topic, err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the writer
+func (p *Producer[T]) Close() error {
+	return p.writer.Close()
+}
+
+// headersKey is the context key for propagated headers
+type headersKey struct{}
+
+// ContextWithHeaders returns a context carrying headers to add to produced
+// messages, e.g. the trace headers of a consumed message
+func ContextWithHeaders(ctx context.Context, headers ...kafka.Header) context.Context {
+	existing := HeadersFromContext(ctx)
+	merged := make([]kafka.Header, 0, len(existing)+len(headers))
+	merged = append(merged, existing...)
+	return context.WithValue(ctx, headersKey{}, append(merged, headers...))
+}
+
+// HeadersFromContext returns the headers attached with ContextWithHeaders
+func HeadersFromContext(ctx context.Context) []kafka.Header {
+	headers, _ := ctx.Value(headersKey{}).([]kafka.Header)
+	return headers
+}
@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers written by the retry pipeline
+const (
+	HeaderAttempt           = "x-retry-attempt"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderLastError         = "x-last-error"
+	HeaderNotBefore         = "x-retry-not-before"
+)
+
+// RetryConfig configures delayed retry topics and the dead-letter topic
+type RetryConfig struct {
+	// Delays lists the wait before each retry, e.g. 1m, 10m, 1h. A message is
+	// attempted len(Delays)+1 times before it goes to the dead-letter topic.
+	Delays []time.Duration
+	// DeadLetterTopic defaults to "<topic>.dlq"
+	DeadLetterTopic string
+}
+
+// RetryPipeline consumes a topic and its retry topics, moving failed messages
+// through the delayed retry topics and finally to the dead-letter topic
+type RetryPipeline struct {
+	client  KafkaClient
+	topic   string
+	groupID string
+	cfg     RetryConfig
+	handler MessageHandler
+}
+
+// NewRetryPipeline creates a pipeline for topic. Retry topics are named
+// "<topic>.retry.<delay>", e.g. "orders.retry.10m0s", and must exist.
+func NewRetryPipeline(client KafkaClient, topic, groupID string, cfg RetryConfig, handler MessageHandler) *RetryPipeline {
+	if cfg.DeadLetterTopic == "" {
+		cfg.DeadLetterTopic = topic + ".dlq"
+	}
+	return &RetryPipeline{client: client, topic: topic, groupID: groupID, cfg: cfg, handler: handler}
+}
+
+// RetryTopics returns the names of the retry topics in order
+func (p *RetryPipeline) RetryTopics() []string {
+	topics := make([]string, len(p.cfg.Delays))
+	for i, delay := range p.cfg.Delays {
+		topics[i] = p.topic + ".retry." + delay.String()
+	}
+	return topics
+}
+
+// DeadLetterTopic returns the name of the dead-letter topic
+func (p *RetryPipeline) DeadLetterTopic() string {
+	return p.cfg.DeadLetterTopic
+}
+
+// Run consumes the main topic and every retry topic until ctx is cancelled or a consumer fails
+func (p *RetryPipeline) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	topics := append([]string{p.topic}, p.RetryTopics()...)
+	errs := make(chan error, len(topics))
+	var wg sync.WaitGroup
+	for i, topic := range topics {
+		handler := p.Handler()
+		if i > 0 {
+			handler = p.delayedHandler()
+		}
+		wg.Add(1)
+		go func(topic string, handler MessageHandler) {
+			defer wg.Done()
+			if err := p.client.Consume(ctx, topic, p.groupID, handler); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(topic, handler)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// Handler wraps the pipeline handler so failures are republished instead of
+// returned. Only a failure to republish is returned to the consumer.
+func (p *RetryPipeline) Handler() MessageHandler {
+	return func(ctx context.Context, msg kafka.Message) error {
+		handlerErr := p.handler(ctx, msg)
+		if handlerErr == nil {
+			return nil
+		}
+		return p.republish(ctx, msg, handlerErr)
+	}
+}
+
+// delayedHandler waits until the message's not-before time before handling it
+func (p *RetryPipeline) delayedHandler() MessageHandler {
+	handler := p.Handler()
+	return func(ctx context.Context, msg kafka.Message) error {
+		if notBefore, err := strconv.ParseInt(HeaderValue(msg, HeaderNotBefore), 10, 64); err == nil {
+			if wait := time.Until(time.UnixMilli(notBefore)); wait > 0 {
+				timer := time.NewTimer(wait)
+				defer timer.Stop()
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		return handler(ctx, msg)
+	}
+}
+
+// republish sends a failed message to the next retry topic, or the dead-letter topic
+func (p *RetryPipeline) republish(ctx context.Context, msg kafka.Message, handlerErr error) error {
+	attempt, err := strconv.Atoi(HeaderValue(msg, HeaderAttempt))
+	if err != nil || attempt < 1 {
+		attempt = 1
+	}
+
+	out := kafka.Message{Key: msg.Key, Value: msg.Value, Headers: copyHeaders(msg.Headers)}
+	if HeaderValue(msg, HeaderOriginalTopic) == "" {
+		SetHeader(&out, HeaderOriginalTopic, msg.Topic)
+		SetHeader(&out, HeaderOriginalPartition, strconv.Itoa(msg.Partition))
+		SetHeader(&out, HeaderOriginalOffset, strconv.FormatInt(msg.Offset, 10))
+	}
+	SetHeader(&out, HeaderLastError, handlerErr.Error())
+	SetHeader(&out, HeaderAttempt, strconv.Itoa(attempt+1))
+
+	if attempt <= len(p.cfg.Delays) {
+		out.Topic = p.RetryTopics()[attempt-1]
+		SetHeader(&out, HeaderNotBefore, strconv.FormatInt(time.Now().Add(p.cfg.Delays[attempt-1]).UnixMilli(), 10))
+	} else {
+		out.Topic = p.cfg.DeadLetterTopic
+		SetHeader(&out, HeaderAttempt, strconv.Itoa(attempt))
+		DeleteHeader(&out, HeaderNotBefore)
+	}
+	if err := p.client.ProduceMessages(ctx, out); err != nil {
+		return fmt.Errorf("failed to republish message to %s: %w", out.Topic, err)
+	}
+	return nil
+}
+
+// HeaderValue returns the value of the last header with the given key, or ""
+func HeaderValue(msg kafka.Message, key string) string {
+	for i := len(msg.Headers) - 1; i >= 0; i-- {
+		if msg.Headers[i].Key == key {
+			return string(msg.Headers[i].Value)
+		}
+	}
+	return ""
+}
+
+// SetHeader replaces or appends a header on the message
+func SetHeader(msg *kafka.Message, key, value string) {
+	DeleteHeader(msg, key)
+	msg.Headers = append(msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// DeleteHeader removes every header with the given key
+func DeleteHeader(msg *kafka.Message, key string) {
+	headers := msg.Headers[:0]
+	for _, h := range msg.Headers {
+		if h.Key != key {
+			headers = append(headers, h)
+		}
+	}
+	msg.Headers = headers
+}
+
+// copyHeaders returns a copy that can be modified without touching the original message
+func copyHeaders(headers []kafka.Header) []kafka.Header {
+	return append([]kafka.Header(nil), headers...)
+}
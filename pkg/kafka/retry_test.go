@@ -0,0 +1,138 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// recordingClient is a KafkaClient that records the messages it produces
+type recordingClient struct {
+	KafkaClient
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+}
+
+func (c *recordingClient) ProduceMessages(_ context.Context, messages ...kafka.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.messages = append(c.messages, messages...)
+	return nil
+}
+
+// Messages returns the recorded messages of topic
+func (c *recordingClient) Messages(topic string) []kafka.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var messages []kafka.Message
+	for _, msg := range c.messages {
+		if msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func TestRetryPipelineRepublish(t *testing.T) {
+	delays := []time.Duration{time.Minute, 10 * time.Minute}
+	tests := []struct {
+		name        string
+		attempt     string // x-retry-attempt of the failed message, empty for a first delivery
+		topic       string
+		wantTopic   string
+		wantAttempt string
+		wantDelay   time.Duration // 0 when no not-before header is expected
+	}{
+		{name: "first failure", topic: "orders", wantTopic: "orders.retry.1m0s", wantAttempt: "2", wantDelay: time.Minute},
+		{name: "second failure", attempt: "2", topic: "orders.retry.1m0s", wantTopic: "orders.retry.10m0s", wantAttempt: "3", wantDelay: 10 * time.Minute},
+		{name: "retries exhausted", attempt: "3", topic: "orders.retry.10m0s", wantTopic: "orders.dlq", wantAttempt: "3"},
+		{name: "invalid attempt counts as first", attempt: "x", topic: "orders", wantTopic: "orders.retry.1m0s", wantAttempt: "2", wantDelay: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingClient{}
+			pipeline := NewRetryPipeline(client, "orders", "billing", RetryConfig{Delays: delays}, func(context.Context, kafka.Message) error {
+				return errors.New("payment service down")
+			})
+			msg := kafka.Message{Topic: tt.topic, Partition: 2, Offset: 41, Key: []byte("order-1"), Value: []byte("{}")}
+			if tt.attempt != "" {
+				SetHeader(&msg, HeaderAttempt, tt.attempt)
+				SetHeader(&msg, HeaderOriginalTopic, "orders")
+				SetHeader(&msg, HeaderOriginalOffset, "7")
+				SetHeader(&msg, HeaderNotBefore, "1")
+			}
+
+			start := time.Now()
+			if err := pipeline.Handler()(context.Background(), msg); err != nil {
+				t.Fatalf("handler returned %v, want the failure republished", err)
+			}
+			out := client.Messages(tt.wantTopic)
+			if len(out) != 1 {
+				t.Fatalf("%d messages on %s, want 1", len(out), tt.wantTopic)
+			}
+			got := out[0]
+			if v := HeaderValue(got, HeaderAttempt); v != tt.wantAttempt {
+				t.Errorf("attempt = %q, want %q", v, tt.wantAttempt)
+			}
+			if v := HeaderValue(got, HeaderLastError); v != "payment service down" {
+				t.Errorf("last error = %q", v)
+			}
+			if v := HeaderValue(got, HeaderOriginalTopic); v != "orders" {
+				t.Errorf("original topic = %q, want orders", v)
+			}
+			if tt.attempt == "" {
+				if v := HeaderValue(got, HeaderOriginalOffset); v != "41" {
+					t.Errorf("original offset = %q, want 41", v)
+				}
+			}
+			notBefore := HeaderValue(got, HeaderNotBefore)
+			if tt.wantDelay == 0 {
+				if notBefore != "" {
+					t.Errorf("not-before = %q on the dead-letter topic", notBefore)
+				}
+				return
+			}
+			ms, err := strconv.ParseInt(notBefore, 10, 64)
+			if err != nil {
+				t.Fatalf("not-before = %q: %v", notBefore, err)
+			}
+			if delay := time.UnixMilli(ms).Sub(start); delay < tt.wantDelay-time.Second || delay > tt.wantDelay+time.Second {
+				t.Errorf("retry delayed by %s, want %s", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestRetryPipelineSuccessIsNotRepublished(t *testing.T) {
+	client := &recordingClient{}
+	pipeline := NewRetryPipeline(client, "orders", "billing", RetryConfig{Delays: []time.Duration{time.Minute}}, func(context.Context, kafka.Message) error {
+		return nil
+	})
+	if err := pipeline.Handler()(context.Background(), kafka.Message{Topic: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, topic := range append(pipeline.RetryTopics(), pipeline.DeadLetterTopic()) {
+		if n := len(client.Messages(topic)); n != 0 {
+			t.Errorf("%d messages on %s", n, topic)
+		}
+	}
+}
+
+func TestRetryPipelineRepublishFailure(t *testing.T) {
+	client := &recordingClient{err: errors.New("broker unavailable")}
+	pipeline := NewRetryPipeline(client, "orders", "billing", RetryConfig{}, func(context.Context, kafka.Message) error {
+		return errors.New("failed")
+	})
+	if err := pipeline.Handler()(context.Background(), kafka.Message{Topic: "orders"}); err == nil {
+		t.Fatal("handler succeeded although the message could not be republished")
+	}
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rk-the-dev/golib-core/pkg/security"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// newSASLMechanism returns the configured SASL mechanism, or nil when SASL is disabled
+func newSASLMechanism(cfg KafkaConfig) (sasl.Mechanism, error) {
+	switch strings.ToUpper(cfg.SASLMechanism) {
+	case "", "NONE":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: cfg.SASLUsername, Password: cfg.SASLPassword}, nil
+	case "SCRAM-SHA-256":
+		return newSCRAMMechanism(scram.SHA256, cfg)
+	case "SCRAM-SHA-512":
+		return newSCRAMMechanism(scram.SHA512, cfg)
+	}
+	return nil, fmt.Errorf("unknown Kafka SASL mechanism: %q", cfg.SASLMechanism)
+}
+
+// newSCRAMMechanism creates a SCRAM mechanism with the configured credentials
+func newSCRAMMechanism(algo scram.Algorithm, cfg KafkaConfig) (sasl.Mechanism, error) {
+	mechanism, err := scram.Mechanism(algo, cfg.SASLUsername, cfg.SASLPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka SCRAM mechanism: %w", err)
+	}
+	return mechanism, nil
+}
+
+// newTransport builds the writer transport with the client ID, TLS and SASL settings
+func newTransport(cfg KafkaConfig) (*kafka.Transport, error) {
+	mechanism, err := newSASLMechanism(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := security.BuildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Kafka TLS config: %w", err)
+	}
+	return &kafka.Transport{
+		ClientID:    cfg.ClientID,
+		DialTimeout: cfg.DialTimeout,
+		TLS:         tlsConfig,
+		SASL:        mechanism,
+	}, nil
+}
+
+// newDialer builds the reader dialer with the client ID, TLS and SASL settings
+func newDialer(cfg KafkaConfig) (*kafka.Dialer, error) {
+	mechanism, err := newSASLMechanism(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := security.BuildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Kafka TLS config: %w", err)
+	}
+	timeout := cfg.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	return &kafka.Dialer{
+		ClientID:      cfg.ClientID,
+		Timeout:       timeout,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// newWriter builds a writer from the producer settings in cfg
+func newWriter(cfg KafkaConfig) (*kafka.Writer, error) {
+	acks, err := parseRequiredAcks(cfg.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	balancer, err := parseBalancer(cfg.Balancer)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Transport:    transport,
+		Balancer:     balancer,
+		RequiredAcks: acks,
+		Compression:  compression,
+		BatchSize:    cfg.BatchSize,
+		BatchBytes:   cfg.BatchBytes,
+		BatchTimeout: cfg.BatchTimeout,
+		MaxAttempts:  cfg.MaxAttempts,
+	}, nil
+}
+
+// parseRequiredAcks maps all, one or none to the writer setting
+func parseRequiredAcks(value string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(value) {
+	case "", "all", "-1":
+		return kafka.RequireAll, nil
+	case "one", "1":
+		return kafka.RequireOne, nil
+	case "none", "0":
+		return kafka.RequireNone, nil
+	}
+	return 0, fmt.Errorf("unknown Kafka required acks: %q", value)
+}
+
+// parseCompression maps a codec name to the writer setting; none disables compression
+func parseCompression(value string) (kafka.Compression, error) {
+	switch strings.ToLower(value) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	}
+	return 0, fmt.Errorf("unknown Kafka compression: %q", value)
+}
+
+// parseBalancer maps a balancer name to a partition balancer
+func parseBalancer(value string) (kafka.Balancer, error) {
+	switch strings.ToLower(value) {
+	case "", "least_bytes":
+		return &kafka.LeastBytes{}, nil
+	case "hash":
+		return &kafka.Hash{}, nil
+	case "round_robin":
+		return &kafka.RoundRobin{}, nil
+	case "crc32":
+		return kafka.CRC32Balancer{}, nil
+	case "murmur2":
+		return kafka.Murmur2Balancer{}, nil
+	}
+	return nil, fmt.Errorf("unknown Kafka balancer: %q", value)
+}
@@ -19,8 +19,26 @@ type MetricsHelper interface {
 	Close() error
 }
 
+// CounterAdder is implemented by helpers that can increase a counter by more than 1 at once
+type CounterAdder interface {
+	AddCounter(name string, value float64, labels map[string]string)
+}
+
+// AddCounter increases a counter by n, falling back to n increments when the
+// helper does not implement CounterAdder
+func AddCounter(m MetricsHelper, name string, n int, labels map[string]string) {
+	if adder, ok := m.(CounterAdder); ok {
+		adder.AddCounter(name, float64(n), labels)
+		return
+	}
+	for range n {
+		m.IncrementCounter(name, labels)
+	}
+}
+
 // metricsHelper implements MetricsHelper
 type metricsHelper struct {
+	mu         sync.Mutex
 	counters   map[string]*prometheus.CounterVec
 	gauges     map[string]*prometheus.GaugeVec
 	histograms map[string]*prometheus.HistogramVec
@@ -47,15 +65,24 @@ func NewMetricsHelper() MetricsHelper {
 
 // IncrementCounter increases the counter metric by 1
 func (m *metricsHelper) IncrementCounter(name string, labels map[string]string) {
+	m.AddCounter(name, 1, labels)
+}
+
+// AddCounter increases the counter metric by value, which must not be negative
+func (m *metricsHelper) AddCounter(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if _, exists := m.counters[name]; !exists {
 		m.counters[name] = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: "Counter for " + name}, getLabelKeys(labels))
 		prometheus.MustRegister(m.counters[name])
 	}
-	m.counters[name].With(labels).Inc()
+	m.counters[name].With(labels).Add(value)
 }
 
 // ObserveHistogram records a value in a histogram
 func (m *metricsHelper) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if _, exists := m.histograms[name]; !exists {
 		m.histograms[name] = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: "Histogram for " + name, Buckets: prometheus.DefBuckets}, getLabelKeys(labels))
 		prometheus.MustRegister(m.histograms[name])
@@ -65,6 +92,8 @@ func (m *metricsHelper) ObserveHistogram(name string, value float64, labels map[
 
 // ObserveSummary records a value in a summary
 func (m *metricsHelper) ObserveSummary(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if _, exists := m.summaries[name]; !exists {
 		m.summaries[name] = prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: name, Help: "Summary for " + name}, getLabelKeys(labels))
 		prometheus.MustRegister(m.summaries[name])
@@ -74,6 +103,8 @@ func (m *metricsHelper) ObserveSummary(name string, value float64, labels map[st
 
 // SetGauge sets a gauge metric to a specific value
 func (m *metricsHelper) SetGauge(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if _, exists := m.gauges[name]; !exists {
 		m.gauges[name] = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: "Gauge for " + name}, getLabelKeys(labels))
 		prometheus.MustRegister(m.gauges[name])
@@ -15,6 +15,11 @@ func (m *MockMetricsHelper) IncrementCounter(name string, labels map[string]stri
 	fmt.Println("📊 [Mock] Incremented Counter:", name, labels)
 }
 
+// AddCounter (mock) simulates increasing a counter by value
+func (m *MockMetricsHelper) AddCounter(name string, value float64, labels map[string]string) {
+	fmt.Println("📊 [Mock] Added to Counter:", name, value, labels)
+}
+
 // ObserveHistogram (mock) simulates recording a histogram value
 func (m *MockMetricsHelper) ObserveHistogram(name string, value float64, labels map[string]string) {
 	fmt.Println("📊 [Mock] Observed Histogram:", name, value, labels)
@@ -0,0 +1,50 @@
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig defines client TLS settings shared by the connection configs.
+// Embed it with an envPrefix (e.g. `envPrefix:"MONGO_"`) to get MONGO_TLS_ENABLED etc.
+type TLSConfig struct {
+	Enabled            bool   `env:"TLS_ENABLED" envDefault:"false"`
+	CAFile             string `env:"TLS_CA_FILE"`
+	CertFile           string `env:"TLS_CERT_FILE"`
+	KeyFile            string `env:"TLS_KEY_FILE"`
+	ServerName         string `env:"TLS_SERVER_NAME"`
+	InsecureSkipVerify bool   `env:"TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+}
+
+// BuildTLSConfig returns a *tls.Config for the settings, or nil when TLS is disabled
+func BuildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA file: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,263 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rk-the-dev/golib-core/pkg/server/shutdown"
+)
+
+// Status represents the outcome of a health check
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDown     Status = "down"
+	StatusDegraded Status = "degraded"
+)
+
+// CheckType decides which probes a check participates in
+type CheckType int
+
+const (
+	// Readiness checks gate /readyz (e.g. database, broker connectivity)
+	Readiness CheckType = iota
+	// Liveness checks gate /livez (e.g. deadlock or stuck worker detection)
+	Liveness
+	// Both runs the check for /readyz and /livez
+	Both
+)
+
+const defaultCheckTimeout = 5 * time.Second
+
+// CheckFunc returns nil when the component is healthy
+type CheckFunc func(ctx context.Context) error
+
+// Check describes a named component check
+type Check struct {
+	Name     string
+	Func     CheckFunc
+	Type     CheckType
+	Timeout  time.Duration // Defaults to 5s
+	Critical bool          // A failing critical check fails the probe, otherwise it is reported as degraded
+	CacheTTL time.Duration // Reuse the last result for this long, 0 disables caching
+}
+
+// CheckResult is the JSON detail reported for a single check
+type CheckResult struct {
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Critical  bool      `json:"critical"`
+	Duration  string    `json:"duration"`
+	Cached    bool      `json:"cached"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the JSON body returned by the probe handlers
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// HealthChecker defines the interface for registering checks and serving probes
+type HealthChecker interface {
+	RegisterCheck(check Check)
+	RemoveCheck(name string)
+	Health(ctx context.Context) Report
+	Readiness(ctx context.Context) Report
+	Liveness(ctx context.Context) Report
+	HealthHandler() fiber.Handler
+	ReadinessHandler() fiber.Handler
+	LivenessHandler() fiber.Handler
+	RegisterRoutes(router fiber.Router)
+}
+
+// registeredCheck keeps a check together with its cached result
+type registeredCheck struct {
+	check     Check
+	mu        sync.Mutex
+	last      CheckResult
+	expiresAt time.Time
+}
+
+// healthChecker implements HealthChecker
+type healthChecker struct {
+	checks   map[string]*registeredCheck
+	shutdown shutdown.ShutdownState
+	mu       sync.RWMutex
+}
+
+// NewHealthChecker creates a HealthChecker. When a ShutdownState is given,
+// readiness reports down as soon as graceful shutdown begins; the helper
+// returned by shutdown.NewShutdownHelper implements it. Pass nil to disable.
+func NewHealthChecker(state shutdown.ShutdownState) HealthChecker {
+	return &healthChecker{
+		checks:   make(map[string]*registeredCheck),
+		shutdown: state,
+	}
+}
+
+// RegisterCheck adds or replaces a named check
+func (h *healthChecker) RegisterCheck(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultCheckTimeout
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[check.Name] = &registeredCheck{check: check}
+}
+
+// RemoveCheck unregisters a check by name
+func (h *healthChecker) RemoveCheck(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.checks, name)
+}
+
+// Health runs every registered check
+func (h *healthChecker) Health(ctx context.Context) Report {
+	return h.run(ctx, func(CheckType) bool { return true })
+}
+
+// Readiness runs readiness checks and fails once shutdown has started
+func (h *healthChecker) Readiness(ctx context.Context) Report {
+	report := h.run(ctx, func(t CheckType) bool { return t == Readiness || t == Both })
+	if h.shutdown != nil && h.shutdown.IsShuttingDown() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = CheckResult{
+			Status:    StatusDown,
+			Error:     "graceful shutdown in progress",
+			Critical:  true,
+			Duration:  "0s",
+			CheckedAt: time.Now(),
+		}
+	}
+	return report
+}
+
+// Liveness runs liveness checks
+func (h *healthChecker) Liveness(ctx context.Context) Report {
+	return h.run(ctx, func(t CheckType) bool { return t == Liveness || t == Both })
+}
+
+// HealthHandler serves /healthz
+func (h *healthChecker) HealthHandler() fiber.Handler {
+	return reportHandler(h.Health)
+}
+
+// ReadinessHandler serves /readyz
+func (h *healthChecker) ReadinessHandler() fiber.Handler {
+	return reportHandler(h.Readiness)
+}
+
+// LivenessHandler serves /livez
+func (h *healthChecker) LivenessHandler() fiber.Handler {
+	return reportHandler(h.Liveness)
+}
+
+// RegisterRoutes mounts /healthz, /readyz and /livez on the given router
+func (h *healthChecker) RegisterRoutes(router fiber.Router) {
+	router.Get("/healthz", h.HealthHandler())
+	router.Get("/readyz", h.ReadinessHandler())
+	router.Get("/livez", h.LivenessHandler())
+}
+
+// run executes the selected checks concurrently and aggregates their results
+func (h *healthChecker) run(ctx context.Context, include func(CheckType) bool) Report {
+	h.mu.RLock()
+	selected := make([]*registeredCheck, 0, len(h.checks))
+	for _, rc := range h.checks {
+		if include(rc.check.Type) {
+			selected = append(selected, rc)
+		}
+	}
+	h.mu.RUnlock()
+	sort.Slice(selected, func(i, j int) bool { return selected[i].check.Name < selected[j].check.Name })
+
+	results := make([]CheckResult, len(selected))
+	var wg sync.WaitGroup
+	for i, rc := range selected {
+		wg.Add(1)
+		go func(i int, rc *registeredCheck) {
+			defer wg.Done()
+			results[i] = rc.evaluate(ctx)
+		}(i, rc)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(selected))}
+	for i, rc := range selected {
+		result := results[i]
+		report.Checks[rc.check.Name] = result
+		if result.Status != StatusDown {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// evaluate returns the cached result if still fresh, otherwise runs the check
+func (rc *registeredCheck) evaluate(ctx context.Context) CheckResult {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.check.CacheTTL > 0 && time.Now().Before(rc.expiresAt) {
+		cached := rc.last
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, rc.check.Timeout)
+	defer cancel()
+	start := time.Now()
+	errChan := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errChan <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		errChan <- rc.check.Func(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-checkCtx.Done():
+		err = fmt.Errorf("check timed out after %s", rc.check.Timeout)
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		Critical:  rc.check.Critical,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	rc.last = result
+	rc.expiresAt = start.Add(rc.check.CacheTTL)
+	return result
+}
+
+// reportHandler renders a report as JSON with 200 or 503 depending on its status
+func reportHandler(probe func(ctx context.Context) Report) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := probe(c.UserContext())
+		status := fiber.StatusOK
+		if report.Status == StatusDown {
+			status = fiber.StatusServiceUnavailable
+		}
+		return c.Status(status).JSON(report)
+	}
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeState is a shutdown.ShutdownState the tests can flip
+type fakeState struct {
+	atomic.Bool
+}
+
+func (s *fakeState) IsShuttingDown() bool {
+	return s.Load()
+}
+
+func newApp(h HealthChecker) *fiber.App {
+	app := fiber.New()
+	h.RegisterRoutes(app)
+	return app
+}
+
+// probe requests path and decodes the report
+func probe(t *testing.T, app *fiber.App, path string) (int, Report) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, report
+}
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func TestProbeStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []Check
+		path       string
+		wantCode   int
+		wantStatus Status
+	}{
+		{"all passing", []Check{{Name: "db", Func: passing, Critical: true}}, "/readyz", fiber.StatusOK, StatusUp},
+		{"critical failing", []Check{
+			{Name: "db", Func: failing, Critical: true},
+			{Name: "cache", Func: passing},
+		}, "/readyz", fiber.StatusServiceUnavailable, StatusDown},
+		{"non-critical failing", []Check{
+			{Name: "db", Func: passing, Critical: true},
+			{Name: "cache", Func: failing},
+		}, "/readyz", fiber.StatusOK, StatusDegraded},
+		{"critical and non-critical failing", []Check{
+			{Name: "db", Func: failing, Critical: true},
+			{Name: "cache", Func: failing},
+		}, "/healthz", fiber.StatusServiceUnavailable, StatusDown},
+		{"readiness check ignored by liveness", []Check{
+			{Name: "db", Func: failing, Critical: true},
+			{Name: "worker", Func: passing, Type: Liveness, Critical: true},
+		}, "/livez", fiber.StatusOK, StatusUp},
+		{"liveness check ignored by readiness", []Check{
+			{Name: "worker", Func: failing, Type: Liveness, Critical: true},
+		}, "/readyz", fiber.StatusOK, StatusUp},
+		{"both types run for liveness", []Check{
+			{Name: "worker", Func: failing, Type: Both, Critical: true},
+		}, "/livez", fiber.StatusServiceUnavailable, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthChecker(nil)
+			for _, check := range tt.checks {
+				h.RegisterCheck(check)
+			}
+			code, report := probe(t, newApp(h), tt.path)
+			if code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Fatalf("got %d %q, want %d %q", code, report.Status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckResultsAreCached(t *testing.T) {
+	var calls atomic.Int32
+	check := func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}
+	h := NewHealthChecker(nil)
+	h.RegisterCheck(Check{Name: "cached", Func: check, CacheTTL: time.Hour})
+	h.RegisterCheck(Check{Name: "uncached", Func: check})
+	app := newApp(h)
+
+	_, first := probe(t, app, "/healthz")
+	_, second := probe(t, app, "/healthz")
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("checks ran %d times, want 3", n)
+	}
+	if first.Checks["cached"].Cached || !second.Checks["cached"].Cached {
+		t.Errorf("cached flags: first %v, second %v", first.Checks["cached"].Cached, second.Checks["cached"].Cached)
+	}
+	if second.Checks["uncached"].Cached {
+		t.Error("check without CacheTTL reported as cached")
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	h := NewHealthChecker(nil)
+	h.RegisterCheck(Check{Name: "stuck", Critical: true, Timeout: 50 * time.Millisecond, Func: func(context.Context) error {
+		<-block
+		return nil
+	}})
+
+	start := time.Now()
+	code, report := probe(t, newApp(h), "/readyz")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probe took %s, want it bounded by the check timeout", elapsed)
+	}
+	if code != fiber.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", code)
+	}
+	if result := report.Checks["stuck"]; !strings.Contains(result.Error, "timed out") {
+		t.Errorf("error %q, want a timeout", result.Error)
+	}
+}
+
+func TestReadinessFailsDuringShutdown(t *testing.T) {
+	state := &fakeState{}
+	h := NewHealthChecker(state)
+	h.RegisterCheck(Check{Name: "db", Func: passing, Type: Both, Critical: true})
+	app := newApp(h)
+
+	if code, _ := probe(t, app, "/readyz"); code != fiber.StatusOK {
+		t.Fatalf("before shutdown /readyz returned %d", code)
+	}
+	state.Store(true)
+	code, report := probe(t, app, "/readyz")
+	if code != fiber.StatusServiceUnavailable || report.Checks["shutdown"].Status != StatusDown {
+		t.Errorf("during shutdown /readyz returned %d %+v", code, report)
+	}
+	if code, _ := probe(t, app, "/livez"); code != fiber.StatusOK {
+		t.Errorf("during shutdown /livez returned %d, want 200", code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -16,10 +17,17 @@ type ShutdownHelper interface {
 	WaitForShutdown()
 }
 
+// ShutdownState is implemented by shutdown helpers that report whether
+// graceful shutdown has begun
+type ShutdownState interface {
+	IsShuttingDown() bool
+}
+
 // shutdownHelper implements ShutdownHelper
 type shutdownHelper struct {
-	hooks map[string][]func(ctx context.Context)
-	mu    sync.Mutex
+	hooks        map[string][]func(ctx context.Context)
+	mu           sync.Mutex
+	shuttingDown atomic.Bool
 }
 
 var (
@@ -49,6 +57,7 @@ func (s *shutdownHelper) WaitForShutdown() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan // Wait for signal
+	s.shuttingDown.Store(true)
 	fmt.Println("\n🔻 Graceful shutdown initiated...")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -62,3 +71,8 @@ func (s *shutdownHelper) WaitForShutdown() {
 	}
 	fmt.Println("✅ Shutdown complete.")
 }
+
+// IsShuttingDown reports whether a termination signal has been received
+func (s *shutdownHelper) IsShuttingDown() bool {
+	return s.shuttingDown.Load()
+}